@@ -0,0 +1,247 @@
+// Command migrate runs the database migrations with the migration package.
+//
+// Usage:
+//
+//	migrate [flags] <command> [argument]
+//
+// Commands:
+//
+//	up [N]          apply all new migrations or N of them
+//	down [N]        roll back the latest applied migration or N of them
+//	goto VERSION    apply or roll back migrations until the database is at VERSION
+//	force VERSION   set VERSION without running migrations, e.g. to clear the dirty flag
+//	drop            delete everything in the database, requires -allow-drop
+//	status          list the migration files with their state
+//	wait [VERSION]  wait until the latest version or VERSION is applied by another instance
+//	recover         roll back the failed migration with its down script and clear the dirty flag
+//	create NAME     create empty up and down migration files
+//	lint            report dangerous operations in the migration files
+//
+// The flags default to the MIGRATION_* environment variables read by migration.RunMigrationsFromEnv.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	_ "github.com/golang-migrate/migrate/v4/database/mongodb"  // mongodb:// database driver
+	_ "github.com/golang-migrate/migrate/v4/database/postgres" // postgres:// database driver
+	"github.com/sirupsen/logrus"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/migration"
+)
+
+const (
+	commandUp      = "up"
+	commandDown    = "down"
+	commandGoto    = "goto"
+	commandForce   = "force"
+	commandDrop    = "drop"
+	commandStatus  = "status"
+	commandCreate  = "create"
+	commandWait    = "wait"
+	commandRecover = "recover"
+	commandLint    = "lint"
+)
+
+type flags struct {
+	dsn          string
+	filesDir     string
+	steps        int
+	version      uint
+	forceVersion int
+	allowDrop    bool
+	naming       string
+	lockName     string
+	lockTimeout  time.Duration
+	waitTimeout  time.Duration
+	dryRun       bool
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: migrate [flags] up [N] | down [N] | goto VERSION | force VERSION | drop | status |")
+		fmt.Fprintln(fs.Output(), "                       wait [VERSION] | recover | create NAME | lint")
+		fs.PrintDefaults()
+	}
+
+	var (
+		f   flags
+		env migration.EnvReader
+	)
+	fs.StringVar(&f.dsn, "dsn", os.Getenv(migration.EnvKeyDsn), "database connection string (MIGRATION_DSN)")
+	fs.StringVar(&f.filesDir, "dir", envOr(migration.EnvKeyFilesDir, "dbmigrations"),
+		"directory with migration files (MIGRATION_FILES_DIR)")
+	fs.IntVar(&f.steps, "steps", env.Int(migration.EnvKeySteps),
+		"number of migrations for up and down (MIGRATION_STEPS)")
+	fs.UintVar(&f.version, "version", env.Uint(migration.EnvKeyVersion),
+		"target version for goto and wait (MIGRATION_VERSION)")
+	fs.IntVar(&f.forceVersion, "force-version", env.Int(migration.EnvKeyForceVersion),
+		"version for force (MIGRATION_FORCE_VERSION)")
+	fs.BoolVar(&f.allowDrop, "allow-drop", env.Bool(migration.EnvKeyAllowDrop), "allow drop (MIGRATION_ALLOW_DROP)")
+	fs.StringVar(&f.naming, "naming", envOr(migration.EnvKeyNaming, string(migration.NamingTimestamp)),
+		"version naming for create: timestamp or sequential (MIGRATION_NAMING)")
+	fs.StringVar(&f.lockName, "lock-name", os.Getenv(migration.EnvKeyLockName),
+		"migrations lock name (MIGRATION_LOCK_NAME)")
+	fs.DurationVar(&f.lockTimeout, "lock-timeout", env.Duration(migration.EnvKeyLockTimeout),
+		"migrations lock timeout, the lock is taken if set (MIGRATION_LOCK_TIMEOUT)")
+	fs.DurationVar(&f.waitTimeout, "wait-timeout", env.Duration(migration.EnvKeyWaitTimeout),
+		"timeout for wait, infinite if not set, required without lock-timeout (MIGRATION_WAIT_TIMEOUT)")
+	fs.BoolVar(&f.dryRun, "dry-run", env.Bool(migration.EnvKeyDryRun),
+		"only log what recover would do (MIGRATION_DRY_RUN)")
+
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := env.Err(); err != nil {
+		return err
+	}
+	if f.steps < 0 {
+		return errors.New("steps must not be negative")
+	}
+
+	if fs.NArg() == 0 || fs.NArg() > 2 {
+		fs.Usage()
+		return errors.New("command is required")
+	}
+
+	command, argument := fs.Arg(0), fs.Arg(1)
+
+	switch command {
+	case commandCreate:
+		return create(f, argument)
+	case commandLint:
+		return lint(f)
+	}
+
+	op, err := operation(f, command, argument)
+	if err != nil {
+		return err
+	}
+
+	if f.dsn == "" {
+		return errors.New("dsn is required")
+	}
+
+	opts := []migration.Option{migration.WithFilesDir(f.filesDir), migration.WithLogger(logrus.StandardLogger())}
+	if f.lockTimeout > 0 {
+		opts = append(opts, migration.WithLock(f.lockName, f.lockTimeout))
+	}
+
+	runner, err := migration.NewRunner(f.dsn, opts...)
+	if err != nil {
+		return fmt.Errorf("new migrations runner: %w", err)
+	}
+	defer runner.Close() //nolint:errcheck // nothing to do with the error on exit
+
+	if command == commandStatus {
+		return status(runner)
+	}
+
+	return runner.Run(op)
+}
+
+// operation builds the migration operation of the command, the argument overrides the flags.
+func operation(f flags, command, argument string) (migration.OperationData, error) {
+	op := migration.OperationData{
+		ID:           command,
+		Steps:        f.steps,
+		Version:      f.version,
+		ForceVersion: f.forceVersion,
+		AllowDrop:    f.allowDrop,
+		Timeout:      f.waitTimeout,
+		DryRun:       f.dryRun,
+	}
+
+	if argument == "" {
+		return op, nil
+	}
+
+	var err error
+	switch command {
+	case commandUp, commandDown:
+		op.Steps, err = strconv.Atoi(argument)
+		if err == nil && op.Steps < 0 {
+			err = errors.New("must not be negative")
+		}
+	case commandGoto, commandWait:
+		var version uint64
+		version, err = strconv.ParseUint(argument, 10, 0)
+		op.Version = uint(version)
+	case commandForce:
+		op.ForceVersion, err = strconv.Atoi(argument)
+	default:
+		return op, fmt.Errorf("command %s doesn't accept arguments", command)
+	}
+	if err != nil {
+		return op, fmt.Errorf("invalid argument %q of command %s: %w", argument, command, err)
+	}
+
+	return op, nil
+}
+
+func create(f flags, name string) error {
+	paths, err := migration.CreateMigration(f.filesDir, name, migration.Naming(f.naming))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+	return nil
+}
+
+func lint(f flags) error {
+	findings, err := migration.Lint(os.DirFS(f.filesDir), ".")
+	if err != nil {
+		return err
+	}
+
+	for _, finding := range findings {
+		finding.File = filepath.Join(f.filesDir, finding.File)
+		fmt.Println(finding)
+	}
+	if len(findings) > 0 {
+		return fmt.Errorf("found %d dangerous operations", len(findings))
+	}
+	return nil
+}
+
+func status(runner *migration.Runner) error {
+	statuses, err := runner.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE")
+	for _, s := range statuses {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Identifier, s.State())
+	}
+	return w.Flush()
+}
+
+func envOr(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
@@ -1,5 +1,7 @@
 package errlib
 
+import "errors"
+
 // AppError is an interface that abstracts application layer specific errors.
 type AppError interface {
 	Error() string
@@ -8,9 +10,10 @@ type AppError interface {
 }
 
 type appError struct {
-	err  error
-	code Code
-	slug Slug
+	err    error
+	code   Code
+	slug   Slug
+	detail string
 }
 
 // NewAppError creates a new application error.
@@ -22,6 +25,26 @@ func NewAppError(err error, code Code, slug Slug) AppError {
 	}
 }
 
+// NewAppErrorWithDetail creates a new application error with a detail, e.g. the name of the violated constraint.
+func NewAppErrorWithDetail(err error, code Code, slug Slug, detail string) AppError {
+	return appError{
+		err:    err,
+		code:   code,
+		slug:   slug,
+		detail: detail,
+	}
+}
+
+// Detail returns the detail of the application error, or an empty string if there is none.
+func Detail(err error) string {
+	var detailed interface{ Detail() string }
+	if errors.As(err, &detailed) {
+		return detailed.Detail()
+	}
+
+	return ""
+}
+
 func (e appError) Error() string {
 	if e.err != nil {
 		return e.err.Error()
@@ -30,6 +53,12 @@ func (e appError) Error() string {
 	return ""
 }
 
+// Unwrap returns the cause of the application error, so errors.Is and errors.As can inspect it,
+// e.g. to retry a transaction failed with a translated database error.
+func (e appError) Unwrap() error {
+	return e.err
+}
+
 func (e appError) Code() Code {
 	return e.code
 }
@@ -37,3 +66,7 @@ func (e appError) Code() Code {
 func (e appError) Slug() Slug {
 	return e.slug
 }
+
+func (e appError) Detail() string {
+	return e.detail
+}
@@ -9,6 +9,8 @@ const (
 	SlugInternal           Slug = "internal"
 	SlugBadGateway         Slug = "bad_gateway"
 	SlugInvalidBodyRequest Slug = "invalid_body_request"
+	SlugNotFound           Slug = "not_found"
+	SlugAlreadyExists      Slug = "already_exists"
 )
 
 // Sessions
@@ -23,3 +25,26 @@ const (
 
 	SlugInvalidAccessToken Slug = "invalid_access_token"
 )
+
+// Pagination
+const (
+	SlugInvalidLimit  Slug = "invalid_limit"
+	SlugInvalidOffset Slug = "invalid_offset"
+	SlugInvalidSort   Slug = "invalid_sort"
+	SlugInvalidCursor Slug = "invalid_cursor"
+)
+
+// Filtering
+const (
+	SlugInvalidFilter Slug = "invalid_filter"
+)
+
+// Database
+const (
+	SlugReferenceViolation   Slug = "reference_violation"
+	SlugConstraintViolation  Slug = "constraint_violation"
+	SlugRequiredFieldMissing Slug = "required_field_missing"
+	SlugConcurrentUpdate     Slug = "concurrent_update"
+	SlugRequestCanceled      Slug = "request_canceled"
+	SlugDatabaseUnavailable  Slug = "database_unavailable"
+)
@@ -0,0 +1,217 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kodenkai-labs/go-lib/errlib"
+	"github.com/kodenkai-labs/go-lib/pagination"
+)
+
+var ErrInvalidFilter = errors.New("invalid filter")
+
+const (
+	filterQueryParam = "filter"
+	sortQueryParam   = "sort"
+	valuesSeparator  = ","
+)
+
+// filterKeyRegex matches the filter query parameter keys: filter[field] and filter[field][operator].
+var filterKeyRegex = regexp.MustCompile(`^filter\[([^\[\]]+)\](?:\[([^\[\]]+)\])?$`)
+
+type Operator string
+
+const (
+	OperatorEq       Operator = "eq"
+	OperatorNe       Operator = "ne"
+	OperatorGt       Operator = "gt"
+	OperatorGte      Operator = "gte"
+	OperatorLt       Operator = "lt"
+	OperatorLte      Operator = "lte"
+	OperatorIn       Operator = "in"
+	OperatorNin      Operator = "nin"
+	OperatorContains Operator = "contains"
+)
+
+// Type is the type of the field values.
+type Type int
+
+const (
+	TypeString Type = iota
+	TypeInt
+	TypeFloat
+	TypeBool
+	// TypeTime values are parsed in RFC 3339 format.
+	TypeTime
+)
+
+// Field describes a field allowed for filtering.
+type Field struct {
+	Type Type
+
+	// Operators is the list of the allowed operators. If empty, only OperatorEq is allowed.
+	Operators []Operator
+
+	// Column is the column or the document key the field is stored in. If empty, the field name is used.
+	Column string
+}
+
+// Schema is the whitelist of the fields and operators of a resource.
+type Schema struct {
+	// Fields is the fields allowed for filtering by their names in the query.
+	Fields map[string]Field
+
+	// Sort is the field names allowed for sorting. If a field is listed in Fields, its Column is used.
+	Sort []string
+}
+
+// Condition is a single filtering condition.
+type Condition struct {
+	// Column is the column or the document key.
+	Column   string
+	Operator Operator
+
+	// Value is the typed value. It is a []any for OperatorIn and OperatorNin.
+	Value any
+}
+
+// Filter is the parsed filtering and sorting of a request.
+type Filter struct {
+	Conditions []Condition
+	Sort       []pagination.SortField
+}
+
+// Parse parses the filter and sort query parameters, e.g.
+// "filter[status]=active&filter[created_at][gte]=2024-01-01T00:00:00Z&filter[role][in]=admin,owner&sort=-created_at".
+// Values of OperatorIn and OperatorNin are comma-separated.
+// Validation errors are returned as errlib.AppError with errlib.InvalidInputCode.
+func (s Schema) Parse(query url.Values) (Filter, error) {
+	var f Filter
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	// keep the conditions order stable
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if !strings.HasPrefix(key, filterQueryParam+"[") {
+			continue
+		}
+
+		condition, err := s.parseCondition(key, query.Get(key))
+		if err != nil {
+			return Filter{}, errlib.NewAppError(
+				fmt.Errorf("%w: %w", ErrInvalidFilter, err), errlib.InvalidInputCode, errlib.SlugInvalidFilter)
+		}
+
+		f.Conditions = append(f.Conditions, condition)
+	}
+
+	sort, err := pagination.ParseSort(query.Get(sortQueryParam), s.Sort...)
+	if err != nil {
+		return Filter{}, err
+	}
+
+	for i := range sort {
+		if field, ok := s.Fields[sort[i].Field]; ok && field.Column != "" {
+			sort[i].Field = field.Column
+		}
+	}
+	f.Sort = sort
+
+	return f, nil
+}
+
+func (s Schema) parseCondition(key, rawValue string) (Condition, error) {
+	matches := filterKeyRegex.FindStringSubmatch(key)
+	if matches == nil {
+		return Condition{}, fmt.Errorf("malformed parameter %q", key)
+	}
+
+	name, operator := matches[1], Operator(matches[2])
+	if operator == "" {
+		operator = OperatorEq
+	}
+
+	field, ok := s.Fields[name]
+	if !ok {
+		return Condition{}, fmt.Errorf("field %q is not allowed", name)
+	}
+
+	allowed := field.Operators
+	if len(allowed) == 0 {
+		allowed = []Operator{OperatorEq}
+	}
+	if !slices.Contains(allowed, operator) {
+		return Condition{}, fmt.Errorf("operator %q is not allowed for field %q", operator, name)
+	}
+
+	column := field.Column
+	if column == "" {
+		column = name
+	}
+
+	var (
+		value any
+		err   error
+	)
+
+	switch operator {
+	case OperatorIn, OperatorNin:
+		value, err = parseValues(field.Type, rawValue)
+	case OperatorContains:
+		if field.Type != TypeString {
+			return Condition{}, fmt.Errorf("operator %q is supported for string fields only", operator)
+		}
+		value = rawValue
+	case OperatorEq, OperatorNe, OperatorGt, OperatorGte, OperatorLt, OperatorLte:
+		value, err = parseValue(field.Type, rawValue)
+	default:
+		return Condition{}, fmt.Errorf("unknown operator %q", operator)
+	}
+	if err != nil {
+		return Condition{}, fmt.Errorf("field %q: %w", name, err)
+	}
+
+	return Condition{Column: column, Operator: operator, Value: value}, nil
+}
+
+func parseValues(fieldType Type, raw string) ([]any, error) {
+	parts := strings.Split(raw, valuesSeparator)
+
+	values := make([]any, 0, len(parts))
+	for _, part := range parts {
+		value, err := parseValue(fieldType, part)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+func parseValue(fieldType Type, raw string) (any, error) {
+	switch fieldType {
+	case TypeString:
+		return raw, nil
+	case TypeInt:
+		return strconv.ParseInt(raw, 10, 64)
+	case TypeFloat:
+		return strconv.ParseFloat(raw, 64)
+	case TypeBool:
+		return strconv.ParseBool(raw)
+	case TypeTime:
+		return time.Parse(time.RFC3339, raw)
+	default:
+		return nil, fmt.Errorf("unknown field type %d", fieldType)
+	}
+}
@@ -0,0 +1,111 @@
+package filter_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kodenkai-labs/go-lib/errlib"
+	"github.com/kodenkai-labs/go-lib/filter"
+	"github.com/kodenkai-labs/go-lib/pagination"
+)
+
+var schema = filter.Schema{
+	Fields: map[string]filter.Field{
+		"status": {Type: filter.TypeString, Operators: []filter.Operator{filter.OperatorEq, filter.OperatorIn}},
+		"age":    {Type: filter.TypeInt, Operators: []filter.Operator{filter.OperatorGte, filter.OperatorLt}},
+		"created_at": {
+			Type:      filter.TypeTime,
+			Operators: []filter.Operator{filter.OperatorGte},
+			Column:    "created",
+		},
+		"name": {Type: filter.TypeString, Operators: []filter.Operator{filter.OperatorContains}},
+	},
+	Sort: []string{"created_at", "name"},
+}
+
+func Test_Schema_Parse(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		query   string
+		want    filter.Filter
+		wantErr bool
+	}{
+		{
+			name:  "Empty query",
+			query: "",
+			want:  filter.Filter{},
+		},
+		{
+			name:  "Implicit eq operator and descending sort",
+			query: "filter[status]=active&sort=-created_at&limit=10",
+			want: filter.Filter{
+				Conditions: []filter.Condition{{Column: "status", Operator: filter.OperatorEq, Value: "active"}},
+				Sort:       []pagination.SortField{{Field: "created", Desc: true}},
+			},
+		},
+		{
+			name:  "Typed values",
+			query: "filter[age][gte]=18&filter[age][lt]=65&filter[created_at][gte]=2024-01-01T00:00:00Z",
+			want: filter.Filter{
+				Conditions: []filter.Condition{
+					{Column: "age", Operator: filter.OperatorGte, Value: int64(18)},
+					{Column: "age", Operator: filter.OperatorLt, Value: int64(65)},
+					{Column: "created", Operator: filter.OperatorGte, Value: createdAt},
+				},
+			},
+		},
+		{
+			name:  "In operator",
+			query: "filter[status][in]=active,blocked",
+			want: filter.Filter{
+				Conditions: []filter.Condition{
+					{Column: "status", Operator: filter.OperatorIn, Value: []any{"active", "blocked"}},
+				},
+			},
+		},
+		{
+			name:    "Not allowed field",
+			query:   "filter[password]=secret",
+			wantErr: true,
+		},
+		{
+			name:    "Not allowed operator",
+			query:   "filter[status][ne]=active",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid value",
+			query:   "filter[age][gte]=old",
+			wantErr: true,
+		},
+		{
+			name:    "Not allowed sort",
+			query:   "sort=age",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+
+			got, err := schema.Parse(query)
+			if tt.wantErr {
+				var appErr errlib.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, errlib.InvalidInputCode, appErr.Code())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/postgres"
+)
+
+// ReadYourWritesMiddleware enables read-your-writes consistency for the request context (see
+// postgres.WithReadYourWrites), keyed by the value of the given cookie, e.g. CookieClientIDKey.
+// Handlers must pass c.Request.Context() to postgres.DBGetter.DBFrom.
+func ReadYourWritesMiddleware(cookieName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var key string
+		if cookie, err := c.Request.Cookie(cookieName); err == nil {
+			key = cookie.Value
+		}
+
+		c.Request = c.Request.WithContext(postgres.WithReadYourWrites(c.Request.Context(), key))
+
+		c.Next()
+	}
+}
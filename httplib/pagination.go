@@ -0,0 +1,150 @@
+package httplib
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kodenkai-labs/go-lib/pagination"
+)
+
+// Pagination query parameters
+const (
+	LimitQueryParam  = "limit"
+	OffsetQueryParam = "offset"
+	CursorQueryParam = "cursor"
+	SortQueryParam   = "sort"
+)
+
+// PaginationParams configures parsing of the pagination query parameters.
+type PaginationParams struct {
+	// DefaultLimit is used when the limit is not provided. If zero, pagination.DefaultLimit is used.
+	DefaultLimit int
+
+	// MaxLimit is the maximum accepted limit. If zero, pagination.MaxLimit is used.
+	MaxLimit int
+
+	// AllowedSort is the list of fields the items can be sorted by.
+	AllowedSort []string
+
+	// DefaultSort is used when the sort is not provided.
+	// For keyset pagination, it must end with a unique field, e.g. the primary key.
+	DefaultSort []pagination.SortField
+}
+
+// Links contains the links to the adjacent pages.
+type Links struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// ParseKeyset parses the limit, cursor and sort query parameters to a keyset pagination request.
+// Validation errors are returned as errlib.AppError with errlib.InvalidInputCode.
+func ParseKeyset(c *gin.Context, codec *pagination.Codec, params PaginationParams) (pagination.Keyset, error) {
+	limit, sort, err := parseLimitAndSort(c, params)
+	if err != nil {
+		return pagination.Keyset{}, err
+	}
+
+	var cursor *pagination.Cursor
+	if token := c.Query(CursorQueryParam); token != "" {
+		decoded, err := codec.Decode(token)
+		if err != nil {
+			return pagination.Keyset{}, err
+		}
+		cursor = &decoded
+	}
+
+	return pagination.NewKeyset(limit, sort, cursor)
+}
+
+// ParseOffset parses the limit, offset and sort query parameters to an offset pagination request.
+// Validation errors are returned as errlib.AppError with errlib.InvalidInputCode.
+func ParseOffset(c *gin.Context, params PaginationParams) (pagination.Offset, error) {
+	limit, sort, err := parseLimitAndSort(c, params)
+	if err != nil {
+		return pagination.Offset{}, err
+	}
+
+	offset, err := pagination.ParseOffset(c.Query(OffsetQueryParam))
+	if err != nil {
+		return pagination.Offset{}, err
+	}
+
+	return pagination.Offset{Limit: limit, Offset: offset, Sort: sort}, nil
+}
+
+// KeysetLinks renders the links to the adjacent pages of the keyset pagination page
+// by replacing the cursor query parameter of the current request.
+func KeysetLinks[T any](c *gin.Context, codec *pagination.Codec, page pagination.KeysetPage[T]) (Links, error) {
+	var links Links
+
+	if page.Next != nil {
+		token, err := codec.Encode(*page.Next)
+		if err != nil {
+			return Links{}, err
+		}
+		links.Next = linkWithQueryParam(c, CursorQueryParam, token)
+	}
+
+	if page.Prev != nil {
+		token, err := codec.Encode(*page.Prev)
+		if err != nil {
+			return Links{}, err
+		}
+		links.Prev = linkWithQueryParam(c, CursorQueryParam, token)
+	}
+
+	return links, nil
+}
+
+// OffsetLinks renders the links to the adjacent pages of the offset pagination page
+// by replacing the offset query parameter of the current request.
+func OffsetLinks[T any](c *gin.Context, page pagination.OffsetPage[T]) Links {
+	var links Links
+
+	if page.HasNext() {
+		links.Next = linkWithQueryParam(c, OffsetQueryParam, strconv.Itoa(page.Offset+page.Limit))
+	}
+
+	if page.HasPrev() {
+		links.Prev = linkWithQueryParam(c, OffsetQueryParam, strconv.Itoa(max(page.Offset-page.Limit, 0)))
+	}
+
+	return links
+}
+
+func parseLimitAndSort(c *gin.Context, params PaginationParams) (int, []pagination.SortField, error) {
+	defaultLimit, maxLimit := params.DefaultLimit, params.MaxLimit
+	if defaultLimit == 0 {
+		defaultLimit = pagination.DefaultLimit
+	}
+	if maxLimit == 0 {
+		maxLimit = pagination.MaxLimit
+	}
+
+	limit, err := pagination.ParseLimit(c.Query(LimitQueryParam), defaultLimit, maxLimit)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	sort, err := pagination.ParseSort(c.Query(SortQueryParam), params.AllowedSort...)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(sort) == 0 {
+		sort = params.DefaultSort
+	}
+
+	return limit, sort, nil
+}
+
+func linkWithQueryParam(c *gin.Context, key, value string) string {
+	link := *c.Request.URL
+
+	query := link.Query()
+	query.Set(key, value)
+	link.RawQuery = query.Encode()
+
+	return link.RequestURI()
+}
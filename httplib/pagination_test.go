@@ -0,0 +1,224 @@
+package httplib_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kodenkai-labs/go-lib/httplib"
+	"github.com/kodenkai-labs/go-lib/pagination"
+)
+
+var params = httplib.PaginationParams{
+	MaxLimit:    50,
+	AllowedSort: []string{"id", "name"},
+	DefaultSort: []pagination.SortField{{Field: "id"}},
+}
+
+func newContext(target string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+
+	return c
+}
+
+func newCodec(t *testing.T) *pagination.Codec {
+	t.Helper()
+
+	codec, err := pagination.NewCodec([]byte("secret"))
+	require.NoError(t, err)
+
+	return codec
+}
+
+func Test_ParseOffset(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		want    pagination.Offset
+		wantErr error
+	}{
+		{
+			name:   "Defaults",
+			target: "/users",
+			want:   pagination.Offset{Limit: pagination.DefaultLimit, Sort: params.DefaultSort},
+		},
+		{
+			name:   "Query parameters",
+			target: "/users?limit=10&offset=30&sort=-name,id",
+			want: pagination.Offset{
+				Limit:  10,
+				Offset: 30,
+				Sort:   []pagination.SortField{{Field: "name", Desc: true}, {Field: "id"}},
+			},
+		},
+		{
+			name:    "Limit above max",
+			target:  "/users?limit=51",
+			wantErr: pagination.ErrInvalidLimit,
+		},
+		{
+			name:    "Negative offset",
+			target:  "/users?offset=-1",
+			wantErr: pagination.ErrInvalidOffset,
+		},
+		{
+			name:    "Not allowed sort",
+			target:  "/users?sort=password",
+			wantErr: pagination.ErrInvalidSort,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset, err := httplib.ParseOffset(newContext(tt.target), params)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, offset)
+		})
+	}
+}
+
+func Test_ParseKeyset(t *testing.T) {
+	codec := newCodec(t)
+
+	cursor, err := codec.Encode(pagination.Cursor{Sort: "-name,id", Values: []any{"name", 42}, Backward: true})
+	require.NoError(t, err)
+
+	otherSortCursor, err := codec.Encode(pagination.Cursor{Sort: "id", Values: []any{42}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		target  string
+		want    pagination.Keyset
+		wantErr error
+	}{
+		{
+			name:   "First page",
+			target: "/users?limit=10",
+			want:   pagination.Keyset{Limit: 10, Sort: params.DefaultSort},
+		},
+		{
+			name:   "Cursor",
+			target: "/users?sort=-name,id&cursor=" + cursor,
+			want: pagination.Keyset{
+				Limit:    pagination.DefaultLimit,
+				Sort:     []pagination.SortField{{Field: "name", Desc: true}, {Field: "id"}},
+				After:    []any{"name", int64(42)},
+				Backward: true,
+			},
+		},
+		{
+			name:    "Cursor of another sort",
+			target:  "/users?sort=-name,id&cursor=" + otherSortCursor,
+			wantErr: pagination.ErrInvalidCursor,
+		},
+		{
+			name:    "Malformed cursor",
+			target:  "/users?cursor=malformed",
+			wantErr: pagination.ErrInvalidCursor,
+		},
+		{
+			name:    "Invalid limit",
+			target:  "/users?limit=0",
+			wantErr: pagination.ErrInvalidLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyset, err := httplib.ParseKeyset(newContext(tt.target), codec, params)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, keyset)
+		})
+	}
+}
+
+func Test_KeysetLinks(t *testing.T) {
+	codec := newCodec(t)
+
+	next := pagination.Cursor{Sort: "id", Values: []any{int64(3)}}
+	prev := pagination.Cursor{Sort: "id", Values: []any{int64(2)}, Backward: true}
+
+	links, err := httplib.KeysetLinks(newContext("/users?limit=2&cursor=current"), codec, pagination.KeysetPage[int]{
+		Items: []int{2, 3},
+		Next:  &next,
+		Prev:  &prev,
+	})
+	require.NoError(t, err)
+
+	for link, want := range map[string]pagination.Cursor{links.Next: next, links.Prev: prev} {
+		u, err := url.Parse(link)
+		require.NoError(t, err)
+		assert.Equal(t, "/users", u.Path)
+		assert.Equal(t, "2", u.Query().Get("limit"))
+
+		cursor, err := codec.Decode(u.Query().Get("cursor"))
+		require.NoError(t, err)
+		assert.Equal(t, want, cursor)
+	}
+
+	links, err = httplib.KeysetLinks(newContext("/users"), codec, pagination.KeysetPage[int]{})
+	require.NoError(t, err)
+	assert.Equal(t, httplib.Links{}, links)
+}
+
+func Test_OffsetLinks(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		page   pagination.OffsetPage[int]
+		want   httplib.Links
+	}{
+		{
+			name:   "Middle page",
+			target: "/users?limit=10&offset=10&sort=name",
+			page:   pagination.OffsetPage[int]{Items: make([]int, 10), Total: 25, Limit: 10, Offset: 10},
+			want: httplib.Links{
+				Next: "/users?limit=10&offset=20&sort=name",
+				Prev: "/users?limit=10&offset=0&sort=name",
+			},
+		},
+		{
+			name:   "First page",
+			target: "/users?limit=10",
+			page:   pagination.OffsetPage[int]{Items: make([]int, 10), Total: 25, Limit: 10},
+			want:   httplib.Links{Next: "/users?limit=10&offset=10"},
+		},
+		{
+			name:   "Last page after an unaligned offset",
+			target: "/users?limit=10&offset=5",
+			page:   pagination.OffsetPage[int]{Items: make([]int, 10), Total: 15, Limit: 10, Offset: 5},
+			want:   httplib.Links{Prev: "/users?limit=10&offset=0"},
+		},
+		{
+			name:   "Single page",
+			target: "/users",
+			page:   pagination.OffsetPage[int]{Items: make([]int, 3), Total: 3, Limit: 20},
+			want:   httplib.Links{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, httplib.OffsetLinks(newContext(tt.target), tt.page))
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package lock_test
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/kodenkai-labs/go-lib/internal/sqltest"
+)
+
+// newFakeDB returns a fake database emulating session-level advisory locks:
+// a lock belongs to the connection that took it and is released when the connection is closed.
+func newFakeDB() *sqltest.DB {
+	var (
+		mu      sync.Mutex
+		holders = make(map[int64]int)
+	)
+
+	return &sqltest.DB{
+		Exec: func(conn int, query string, args []any) (driver.Result, error) {
+			key, _ := args[0].(int64)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if strings.Contains(query, "pg_advisory_unlock") && holders[key] == conn {
+				delete(holders, key)
+			}
+
+			return driver.RowsAffected(1), nil
+		},
+		Query: func(conn int, query string, args []any) (driver.Rows, error) {
+			if !strings.Contains(query, "pg_try_advisory_lock") {
+				return nil, errors.New("unexpected query: " + query)
+			}
+
+			key, _ := args[0].(int64)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			holder, held := holders[key]
+			acquired := !held || holder == conn
+			if acquired {
+				holders[key] = conn
+			}
+
+			return sqltest.NewRows([]string{"value"}, []driver.Value{acquired}), nil
+		},
+		Close: func(conn int) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			for key, holder := range holders {
+				if holder == conn {
+					delete(holders, key)
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,215 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultElectionInterval = 5 * time.Second
+	releaseTimeout          = 5 * time.Second
+)
+
+// Elector elects a single leader among the replicas competing for the same lock name.
+// It implements service.StartStopper, so it can be run alongside other services.
+type Elector struct {
+	locker   *Locker
+	name     string
+	interval time.Duration
+
+	onElected func(ctx context.Context)
+	onRevoked func()
+
+	mu      sync.RWMutex
+	lease   *Lease
+	cancel  context.CancelFunc
+	leading chan struct{} // closed when onElected returns
+	started bool
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// ElectorOption represents a function that configures an Elector.
+type ElectorOption func(*Elector)
+
+// WithInterval sets how often the Elector tries to acquire the leadership
+// and checks that the held leadership is still valid.
+// If not provided, 5 seconds is used by default.
+func WithInterval(interval time.Duration) ElectorOption {
+	return func(e *Elector) {
+		e.interval = interval
+	}
+}
+
+// WithOnElected sets a callback called in a separate goroutine when the replica becomes the leader.
+// The passed context is canceled as soon as the leadership is lost or the Elector is stopped,
+// so the callback can run the leader job until the context is done. The lock is released
+// only after the callback returns, so it must return once the context is canceled.
+func WithOnElected(fn func(ctx context.Context)) ElectorOption {
+	return func(e *Elector) {
+		e.onElected = fn
+	}
+}
+
+// WithOnRevoked sets a callback called when the replica stops being the leader.
+func WithOnRevoked(fn func()) ElectorOption {
+	return func(e *Elector) {
+		e.onRevoked = fn
+	}
+}
+
+// NewElector creates a new Elector competing for the lock with the given name.
+func NewElector(locker *Locker, name string, opts ...ElectorOption) *Elector {
+	e := &Elector{
+		locker:   locker,
+		name:     name,
+		interval: defaultElectionInterval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// IsLeader reports whether the replica currently holds the leadership.
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.lease != nil
+}
+
+// Start starts the leader election in the background. Only the first call has an effect,
+// and the election isn't started once the Elector is stopped.
+func (e *Elector) Start() {
+	e.startOnce.Do(func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		select {
+		case <-e.stop:
+			return
+		default:
+		}
+
+		e.started = true
+		go e.run()
+	})
+}
+
+// Stop stops the leader election and releases the leadership, if it's held,
+// waiting for the onElected callback to return. It's safe to call it several times and before Start.
+func (e *Elector) Stop() {
+	e.stopOnce.Do(func() {
+		e.mu.Lock()
+		close(e.stop)
+		started := e.started
+		e.mu.Unlock()
+
+		if started {
+			<-e.done
+		}
+
+		logrus.WithField("lock", e.name).Info("leader election stopped")
+	})
+}
+
+func (e *Elector) run() {
+	defer close(e.done)
+
+	logrus.WithField("lock", e.name).Info("starting leader election")
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		e.elect()
+
+		select {
+		case <-e.stop:
+			e.revoke()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) elect() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+	defer cancel()
+
+	if e.IsLeader() {
+		if err := e.lease.Alive(ctx); err != nil {
+			logrus.WithError(err).WithField("lock", e.name).Error("leadership lost")
+			e.revoke()
+		}
+
+		return
+	}
+
+	lease, acquired, err := e.locker.TryLock(ctx, e.name)
+	if err != nil {
+		logrus.WithError(err).WithField("lock", e.name).Error("try to acquire leadership")
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	leaderCtx, leaderCancel := context.WithCancel(context.Background())
+	leading := make(chan struct{})
+
+	e.mu.Lock()
+	e.lease = lease
+	e.cancel = leaderCancel
+	e.leading = leading
+	e.mu.Unlock()
+
+	logrus.WithField("lock", e.name).Info("leadership acquired")
+
+	go func() {
+		defer close(leading)
+
+		if e.onElected != nil {
+			e.onElected(leaderCtx)
+		}
+	}()
+}
+
+func (e *Elector) revoke() {
+	e.mu.Lock()
+	lease, cancel, leading := e.lease, e.cancel, e.leading
+	e.lease, e.cancel, e.leading = nil, nil, nil
+	e.mu.Unlock()
+
+	if lease == nil {
+		return
+	}
+
+	// the leader job must be finished before another replica can take the leadership
+	cancel()
+	<-leading
+
+	ctx, cancelRelease := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancelRelease()
+
+	if err := lease.Release(ctx); err != nil {
+		logrus.WithError(err).WithField("lock", e.name).Error("release leadership")
+	}
+
+	logrus.WithField("lock", e.name).Info("leadership revoked")
+
+	if e.onRevoked != nil {
+		e.onRevoked()
+	}
+}
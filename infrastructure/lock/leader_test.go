@@ -0,0 +1,82 @@
+package lock_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/lock"
+)
+
+const (
+	testInterval = 10 * time.Millisecond
+	testWait     = time.Second
+)
+
+func Test_Elector(t *testing.T) {
+	fake := newFakeDB()
+
+	var running, revoked atomic.Bool
+	leader := lock.NewElector(lock.NewLockerFromDB(fake.Open()), "leader",
+		lock.WithInterval(testInterval),
+		lock.WithOnElected(func(ctx context.Context) {
+			// a leader job running until the leadership is lost
+			running.Store(true)
+			<-ctx.Done()
+			running.Store(false)
+		}),
+		lock.WithOnRevoked(func() {
+			revoked.Store(true)
+		}),
+	)
+	follower := lock.NewElector(lock.NewLockerFromDB(fake.Open()), "leader", lock.WithInterval(testInterval))
+
+	leader.Start()
+	assert.Eventually(t, leader.IsLeader, testWait, testInterval)
+	assert.Eventually(t, running.Load, testWait, testInterval)
+
+	follower.Start()
+	defer follower.Stop()
+	time.Sleep(5 * testInterval)
+	assert.False(t, follower.IsLeader(), "the lock is held by the leader")
+
+	stopped := make(chan struct{})
+	go func() {
+		leader.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(testWait):
+		t.Fatal("Stop is blocked by the leader job")
+	}
+
+	assert.False(t, leader.IsLeader())
+	assert.False(t, running.Load(), "the leader job is finished before the lock is released")
+	assert.True(t, revoked.Load())
+
+	assert.Eventually(t, follower.IsLeader, testWait, testInterval)
+}
+
+func Test_Elector_Stop(t *testing.T) {
+	t.Run("Before start", func(t *testing.T) {
+		elector := lock.NewElector(lock.NewLockerFromDB(newFakeDB().Open()), "leader", lock.WithInterval(testInterval))
+		elector.Stop()
+
+		elector.Start()
+		time.Sleep(5 * testInterval)
+		assert.False(t, elector.IsLeader(), "a stopped elector isn't started")
+	})
+
+	t.Run("Twice", func(t *testing.T) {
+		elector := lock.NewElector(lock.NewLockerFromDB(newFakeDB().Open()), "leader", lock.WithInterval(testInterval))
+		elector.Start()
+
+		elector.Stop()
+		elector.Stop()
+	})
+}
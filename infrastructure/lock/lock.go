@@ -0,0 +1,204 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/postgres"
+)
+
+var ErrLeaseReleased = errors.New("lease already released")
+
+// Locker acquires Postgres session-level advisory locks.
+// Every acquired lock pins a dedicated connection from the source pool for the lifetime of the lease,
+// because session-level advisory locks belong to the connection that took them.
+type Locker struct {
+	db *sql.DB
+}
+
+// NewLocker creates a new Locker on top of the source (read-write) connection of the DBGetter.
+func NewLocker(getter *postgres.DBGetter) (*Locker, error) {
+	sqlDB, err := getter.GetSourceDB().DB()
+	if err != nil {
+		return nil, fmt.Errorf("get sql db: %w", err)
+	}
+
+	return &Locker{db: sqlDB}, nil
+}
+
+// NewLockerFromDB creates a new Locker on top of the given Postgres connection pool.
+func NewLockerFromDB(db *sql.DB) *Locker {
+	return &Locker{db: db}
+}
+
+// TryLock tries to acquire the lock with the given name without waiting.
+// It returns false if the lock is held by another session.
+func (l *Locker) TryLock(ctx context.Context, name string) (*Lease, bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("get connection: %w", err)
+	}
+
+	key := Key(name)
+
+	var acquired bool
+	if err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		_ = conn.Close()
+		return nil, false, fmt.Errorf("try advisory lock %s: %w", name, err)
+	}
+
+	if !acquired {
+		_ = conn.Close()
+		return nil, false, nil
+	}
+
+	return &Lease{conn: conn, name: name, key: key}, true, nil
+}
+
+// Lock acquires the lock with the given name, waiting until it is released by other sessions
+// or the context is done.
+func (l *Locker) Lock(ctx context.Context, name string) (*Lease, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get connection: %w", err)
+	}
+
+	key := Key(name)
+
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", key); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("advisory lock %s: %w", name, err)
+	}
+
+	return &Lease{conn: conn, name: name, key: key}, nil
+}
+
+// Do runs fc only if the lock with the given name can be acquired immediately and releases it afterwards.
+// It returns false without calling fc if the lock is held by another session.
+func (l *Locker) Do(ctx context.Context, name string, fc func(ctx context.Context) error) (bool, error) {
+	lease, acquired, err := l.TryLock(ctx, name)
+	if err != nil || !acquired {
+		return false, err
+	}
+
+	defer lease.Release(context.WithoutCancel(ctx)) //nolint:errcheck // the connection is closed anyway
+
+	return true, fc(ctx)
+}
+
+// holderQuery finds the session holding the advisory lock.
+// A bigint key is split by Postgres into the high (classid) and low (objid) 32 bits, objsubid is 1 for such keys.
+const holderQuery = `SELECT a.pid, COALESCE(a.application_name, ''), COALESCE(host(a.client_addr), ''), a.backend_start
+FROM pg_locks l
+JOIN pg_stat_activity a ON a.pid = l.pid
+WHERE l.locktype = 'advisory' AND l.granted AND l.objsubid = 1
+	AND l.classid::bigint = $1 AND l.objid::bigint = $2
+LIMIT 1`
+
+// Holder describes the session holding a lock.
+type Holder struct {
+	PID             int
+	ApplicationName string
+	ClientAddr      string
+	ConnectedAt     time.Time
+}
+
+// String returns the description of the holder for logs and errors.
+func (h *Holder) String() string {
+	return fmt.Sprintf("pid %d (application %q, client %q, connected at %s)",
+		h.PID, h.ApplicationName, h.ClientAddr, h.ConnectedAt.Format(time.RFC3339))
+}
+
+// Holder returns the session holding the lock with the given name, or nil if the lock is free.
+// Reading the sessions of other users requires the pg_read_all_stats role, otherwise their details are empty.
+func (l *Locker) Holder(ctx context.Context, name string) (*Holder, error) {
+	key := uint64(Key(name)) //nolint:gosec // the key is split into two unsigned 32-bit halves
+
+	var (
+		holder      Holder
+		connectedAt sql.NullTime
+	)
+	err := l.db.QueryRowContext(ctx, holderQuery, int64(key>>32), int64(key&0xffffffff)).Scan(
+		&holder.PID, &holder.ApplicationName, &holder.ClientAddr, &connectedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get lock %s holder: %w", name, err)
+	}
+	holder.ConnectedAt = connectedAt.Time
+
+	return &holder, nil
+}
+
+// Lease is a held advisory lock. It must be released with Release once the work is done.
+type Lease struct {
+	conn *sql.Conn
+	name string
+	key  int64
+
+	mu       sync.Mutex
+	released bool
+}
+
+// Name returns the name of the held lock.
+func (l *Lease) Name() string {
+	return l.name
+}
+
+// Alive checks that the connection holding the lock is still usable.
+// A session-level lock is lost only when its connection is closed, so a failed check
+// means the lock can no longer be trusted.
+func (l *Lease) Alive(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.released {
+		return ErrLeaseReleased
+	}
+
+	if err := l.conn.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping lock %s connection: %w", l.name, err)
+	}
+
+	return nil
+}
+
+// Release releases the lock and returns the pinned connection to the pool.
+// If the lock can't be released, e.g. the context is done, the connection is discarded instead,
+// so that the lock is released by the end of its session rather than kept by the pool.
+func (l *Lease) Release(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.released {
+		return nil
+	}
+	l.released = true
+
+	if _, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key); err != nil {
+		// returning driver.ErrBadConn makes database/sql close the connection instead of reusing it
+		_ = l.conn.Raw(func(any) error { return driver.ErrBadConn })
+		return fmt.Errorf("release lock %s: %w", l.name, err)
+	}
+
+	if err := l.conn.Close(); err != nil {
+		return fmt.Errorf("release lock %s: %w", l.name, err)
+	}
+
+	return nil
+}
+
+// Key converts the lock name to the 64-bit key used by Postgres advisory lock functions.
+func Key(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+
+	return int64(h.Sum64()) //nolint:gosec // overflow is intended, any 64-bit value is a valid key
+}
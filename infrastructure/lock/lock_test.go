@@ -0,0 +1,80 @@
+package lock_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/lock"
+)
+
+func Test_Locker_TryLock(t *testing.T) {
+	locker := lock.NewLockerFromDB(newFakeDB().Open())
+	ctx := context.Background()
+
+	lease, acquired, err := locker.TryLock(ctx, "job")
+	require.NoError(t, err)
+	require.True(t, acquired)
+	assert.Equal(t, "job", lease.Name())
+	assert.NoError(t, lease.Alive(ctx))
+
+	_, acquired, err = locker.TryLock(ctx, "job")
+	require.NoError(t, err)
+	assert.False(t, acquired, "the lock is held by another session")
+
+	require.NoError(t, lease.Release(ctx))
+	assert.ErrorIs(t, lease.Alive(ctx), lock.ErrLeaseReleased)
+	assert.NoError(t, lease.Release(ctx), "the second release is a no-op")
+
+	lease, acquired, err = locker.TryLock(ctx, "job")
+	require.NoError(t, err)
+	require.True(t, acquired)
+	require.NoError(t, lease.Release(ctx))
+}
+
+func Test_Lease_Release_Failed(t *testing.T) {
+	fake := newFakeDB()
+	db := fake.Open()
+	locker := lock.NewLockerFromDB(db)
+
+	lease, acquired, err := locker.TryLock(context.Background(), "job")
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, lease.Release(canceled))
+
+	// the connection still holding the lock must be discarded rather than returned to the pool
+	_, acquired, err = lock.NewLockerFromDB(fake.Open()).TryLock(context.Background(), "job")
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	assert.Equal(t, 0, db.Stats().OpenConnections)
+}
+
+func Test_Locker_Do(t *testing.T) {
+	locker := lock.NewLockerFromDB(newFakeDB().Open())
+	ctx := context.Background()
+
+	called := false
+	done, err := locker.Do(ctx, "job", func(ctx context.Context) error {
+		called = true
+
+		_, acquired, err := locker.TryLock(ctx, "job")
+		require.NoError(t, err)
+		assert.False(t, acquired)
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, done)
+	assert.True(t, called)
+
+	lease, acquired, err := locker.TryLock(ctx, "job")
+	require.NoError(t, err)
+	assert.True(t, acquired, "the lock is released after fc")
+	require.NoError(t, lease.Release(ctx))
+}
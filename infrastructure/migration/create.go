@@ -0,0 +1,119 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// Naming represents the way the versions of created migration files are chosen.
+type Naming string
+
+// Supported namings of migration files.
+const (
+	// NamingTimestamp uses the current UTC time as the version, e.g. 20250102150405_create_users.up.sql.
+	NamingTimestamp Naming = "timestamp"
+
+	// NamingSequential uses the next number after the latest version in the directory,
+	// e.g. 000002_create_users.up.sql.
+	NamingSequential Naming = "sequential"
+)
+
+const (
+	timestampVersionFormat = "20060102150405"
+	sequentialDigits       = 6
+	migrationExt           = "sql"
+)
+
+// CreateMigration creates the empty up and down migration files with the given name in the directory
+// and returns their paths. The directory is created if it doesn't exist.
+// The name must not contain spaces or path separators, e.g. create_users.
+func CreateMigration(dir, name string, naming Naming) ([]string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("migration name is empty")
+	}
+	if strings.ContainsFunc(name, unicode.IsSpace) || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("migration name %q must not contain spaces or path separators", name)
+	}
+
+	version, err := nextVersion(dir, naming, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create migrations dir: %w", err)
+	}
+
+	paths := make([]string, 0, 2)
+	for _, direction := range []source.Direction{source.Up, source.Down} {
+		path := filepath.Join(dir, fmt.Sprintf("%s_%s.%s.%s", version, name, direction, migrationExt))
+
+		// O_EXCL guarantees the existing migration is never overwritten
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("create migration file: %w", err)
+		}
+		if err = file.Close(); err != nil {
+			return nil, fmt.Errorf("close migration file: %w", err)
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+func nextVersion(dir string, naming Naming, now time.Time) (string, error) {
+	switch naming {
+	case NamingTimestamp:
+		return now.UTC().Format(timestampVersionFormat), nil
+	case NamingSequential:
+		latest, err := latestVersion(dir)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%0*d", sequentialDigits, latest+1), nil
+	default:
+		return "", fmt.Errorf("unsupported migration naming: %s", naming)
+	}
+}
+
+// latestVersion returns the greatest version of the migration files in the directory,
+// or 0 if there are none.
+func latestVersion(dir string) (uint, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read migrations dir %s: %w", dir, err)
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		m, err := source.DefaultParse(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		if m.Version > latest {
+			latest = m.Version
+		}
+	}
+
+	// the timestamp versions can't be continued sequentially
+	if len(strconv.FormatUint(uint64(latest), 10)) > sequentialDigits {
+		return 0, fmt.Errorf("latest version %d is not sequential", latest)
+	}
+
+	return latest, nil
+}
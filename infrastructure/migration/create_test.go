@@ -0,0 +1,96 @@
+package migration_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/migration"
+)
+
+func Test_CreateMigration_Sequential(t *testing.T) {
+	dir := t.TempDir()
+
+	paths, err := migration.CreateMigration(dir, "create_users", migration.NamingSequential)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "000001_create_users.up.sql"),
+		filepath.Join(dir, "000001_create_users.down.sql"),
+	}, paths)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "000007_add_index.up.sql"), nil, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), nil, 0o600))
+
+	paths, err = migration.CreateMigration(dir, "create_orders", migration.NamingSequential)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "000008_create_orders.up.sql"),
+		filepath.Join(dir, "000008_create_orders.down.sql"),
+	}, paths)
+}
+
+func Test_CreateMigration_Timestamp(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "dbmigrations")
+
+	paths, err := migration.CreateMigration(dir, "create_users", migration.NamingTimestamp)
+	require.NoError(t, err)
+	require.Len(t, paths, 2)
+	assert.Regexp(t, regexp.MustCompile(`/\d{14}_create_users\.up\.sql$`), paths[0])
+	assert.Regexp(t, regexp.MustCompile(`/\d{14}_create_users\.down\.sql$`), paths[1])
+
+	for _, path := range paths {
+		assert.FileExists(t, path)
+	}
+}
+
+func Test_CreateMigration_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  []string
+		mig    string
+		naming migration.Naming
+	}{
+		{
+			name:   "Empty name",
+			mig:    " ",
+			naming: migration.NamingSequential,
+		},
+		{
+			name:   "Name with spaces",
+			mig:    "create users",
+			naming: migration.NamingSequential,
+		},
+		{
+			name:   "Name with path separator",
+			mig:    "../create_users",
+			naming: migration.NamingSequential,
+		},
+		{
+			name:   "Unsupported naming",
+			mig:    "create_users",
+			naming: "random",
+		},
+		{
+			name:   "Sequential after timestamp",
+			files:  []string{"20250102150405_create_users.up.sql"},
+			mig:    "create_orders",
+			naming: migration.NamingSequential,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, file), nil, 0o600))
+			}
+
+			_, err := migration.CreateMigration(dir, tt.mig, tt.naming)
+			assert.Error(t, err)
+		})
+	}
+}
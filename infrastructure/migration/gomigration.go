@@ -0,0 +1,189 @@
+package migration
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/source"
+	"go.mongodb.org/mongo-driver/mongo"
+	"gorm.io/gorm"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/postgres"
+)
+
+// goMigrationMarker starts the body of a Go migration in the source,
+// so that the database driver can recognize it and call the function instead of executing SQL.
+const goMigrationMarker = "-- go-lib:go-migration"
+
+// GoMigrationFunc is a migration implemented in Go, e.g. a data backfill which can't be expressed in SQL.
+// It runs in a transaction: tx is the transaction, and ctx carries it for the code using DBGetter.DBFrom.
+type GoMigrationFunc func(ctx context.Context, tx *gorm.DB) error
+
+// migrationTarget is the database a Go migration runs on.
+type migrationTarget int
+
+const (
+	targetPostgres migrationTarget = iota + 1
+	targetMongo
+)
+
+// migrationFunc runs a Go migration on its target database connected by goDriver.
+type migrationFunc func(ctx context.Context, d *goDriver) error
+
+type goMigration struct {
+	version uint
+	name    string
+	target  migrationTarget
+	up      migrationFunc
+	down    migrationFunc
+}
+
+// WithGoMigration registers the migration implemented in Go. It's applied in order of versions
+// along with the migration files and tracked in the same version table, so its version must be unique
+// across the migration files. The down function is optional and can be nil.
+// The transactions are started by the DBGetter provided by WithDBGetter or connected to the dsn.
+func WithGoMigration(version uint, name string, up, down GoMigrationFunc) Option {
+	return func(runner *Runner) {
+		runner.goMigrations = append(runner.goMigrations, goMigration{
+			version: version,
+			name:    name,
+			target:  targetPostgres,
+			up:      postgresMigrationFunc(up),
+			down:    postgresMigrationFunc(down),
+		})
+	}
+}
+
+func postgresMigrationFunc(fn GoMigrationFunc) migrationFunc {
+	if fn == nil {
+		return nil
+	}
+
+	return func(ctx context.Context, d *goDriver) error {
+		return d.getter.Transaction(ctx, func(ctx context.Context) error {
+			return fn(ctx, d.getter.DBFrom(ctx))
+		})
+	}
+}
+
+// WithDBGetter sets the DBGetter running the Go migrations.
+// If not provided, a DBGetter connected to the dsn will be used.
+func WithDBGetter(getter *postgres.DBGetter) Option {
+	return func(runner *Runner) {
+		runner.getter = getter
+	}
+}
+
+// addGoMigrations adds the Go migrations to the source. Their bodies are the markers recognized by goDriver.
+func (s *multiSource) addGoMigrations(migrations []goMigration) error {
+	for _, goMigration := range migrations {
+		for _, direction := range []source.Direction{source.Up, source.Down} {
+			fn := goMigration.up
+			if direction == source.Down {
+				fn = goMigration.down
+			}
+			if fn == nil {
+				continue
+			}
+
+			m := &source.Migration{
+				Version:    goMigration.version,
+				Identifier: goMigration.name,
+				Direction:  direction,
+				Raw:        fmt.Sprintf("%d_%s.%s.go", goMigration.version, goMigration.name, direction),
+			}
+			if !s.migrations.Append(m) {
+				return fmt.Errorf("duplicate migration %s", m.Raw)
+			}
+
+			s.goFuncs[migrationKey{version: m.Version, direction: direction}] = fn
+		}
+	}
+
+	return nil
+}
+
+// goDriver is a database driver running the Go migrations and passing the migration files to the wrapped driver.
+type goDriver struct {
+	database.Driver
+
+	src     *multiSource
+	getter  *postgres.DBGetter
+	mongoDB *mongo.Database
+}
+
+// Run runs the Go migration if the body is its marker, or executes the migration file otherwise.
+func (d *goDriver) Run(migration io.Reader) error {
+	body, err := io.ReadAll(migration)
+	if err != nil {
+		return fmt.Errorf("read migration: %w", err)
+	}
+
+	var (
+		version   uint
+		direction source.Direction
+	)
+	if _, err = fmt.Sscanf(string(body), goMigrationMarker+" %d %s", &version, &direction); err != nil {
+		return d.Driver.Run(bytes.NewReader(body))
+	}
+
+	fn, ok := d.src.goFuncs[migrationKey{version: version, direction: direction}]
+	if !ok {
+		return fmt.Errorf("go migration %d %s is not registered", version, direction)
+	}
+
+	return fn(context.Background(), d)
+}
+
+// hasGoMigrations reports whether any of the Go migrations runs on the target.
+func (m *Runner) hasGoMigrations(target migrationTarget) bool {
+	for _, goMigration := range m.goMigrations {
+		if goMigration.target == target {
+			return true
+		}
+	}
+	return false
+}
+
+// connectGoTargets connects to the databases of the Go migrations, unless the connections are provided by options.
+func (m *Runner) connectGoTargets(dsn string) error {
+	if m.getter == nil && m.hasGoMigrations(targetPostgres) {
+		getter, err := postgres.NewDBGetter(postgres.Config{URI: stripMigrateParams(dsn)})
+		if err != nil {
+			return fmt.Errorf("connecting postgres: %w", err)
+		}
+		m.getter = getter
+		m.ownGetter = true
+	}
+
+	if m.mongoDB == nil && m.hasGoMigrations(targetMongo) {
+		db, err := connectMongo(dsn)
+		if err != nil {
+			return fmt.Errorf("connecting mongo: %w", err)
+		}
+		m.mongoDB = db
+		m.ownMongoDB = true
+	}
+
+	return nil
+}
+
+// closeGoTargets closes the connections opened by connectGoTargets.
+func (m *Runner) closeGoTargets() error {
+	var getterErr, mongoErr error
+	if m.ownGetter {
+		getterErr = m.getter.Close()
+	}
+	if m.ownMongoDB {
+		ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+		defer cancel()
+
+		mongoErr = m.mongoDB.Client().Disconnect(ctx)
+	}
+
+	return errors.Join(getterErr, mongoErr)
+}
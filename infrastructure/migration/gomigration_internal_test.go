@@ -0,0 +1,164 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/postgres"
+	"github.com/kodenkai-labs/go-lib/internal/sqltest"
+)
+
+const (
+	backfillNames = "UPDATE users SET name = 'name'"
+	clearNames    = "UPDATE users SET name = NULL"
+)
+
+// withBackfill registers the Go migration 3 running the statement on the fake database, or failing with err.
+func withBackfill(t *testing.T, fake *sqltest.DB, err error) []Option {
+	t.Helper()
+
+	exec := func(statement string) GoMigrationFunc {
+		return func(_ context.Context, tx *gorm.DB) error {
+			if err != nil {
+				return err
+			}
+			return tx.Exec(statement).Error
+		}
+	}
+
+	return []Option{
+		WithGoMigration(3, "backfill_names", exec(backfillNames), exec(clearNames)),
+		WithDBGetter(postgres.NewDBGetterFromGormInstance(fake.OpenGorm(t))),
+	}
+}
+
+func Test_goDriver_Run(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantErrText  string
+		wantCalled   []string
+		wantSequence []string
+	}{
+		{
+			name:         "Migration file",
+			body:         upUsers,
+			wantSequence: []string{upUsers},
+		},
+		{
+			name:         "Up marker",
+			body:         goMigrationMarker + " 3 up",
+			wantCalled:   []string{"up"},
+			wantSequence: []string{},
+		},
+		{
+			name:         "Down marker",
+			body:         goMigrationMarker + " 3 down",
+			wantCalled:   []string{"down"},
+			wantSequence: []string{},
+		},
+		{
+			name:         "Unregistered marker",
+			body:         goMigrationMarker + " 4 up",
+			wantErrText:  "go migration 4 up is not registered",
+			wantSequence: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called []string
+			record := func(name string) GoMigrationFunc {
+				return func(context.Context, *gorm.DB) error {
+					called = append(called, name)
+					return nil
+				}
+			}
+
+			fake := &sqltest.DB{}
+			runner, db := newStubRunner(t,
+				WithGoMigration(3, "backfill_names", record("up"), record("down")),
+				WithDBGetter(postgres.NewDBGetterFromGormInstance(fake.OpenGorm(t))),
+			)
+
+			err := runner.db.Run(strings.NewReader(tt.body))
+			if tt.wantErrText == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErrText)
+			}
+			assert.Equal(t, tt.wantCalled, called)
+			assert.Equal(t, tt.wantSequence, db.MigrationSequence)
+		})
+	}
+}
+
+func Test_Runner_Run_GoMigration(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name           string
+		current        int
+		op             OperationData
+		err            error
+		wantErr        error
+		wantVersion    int
+		wantDirty      bool
+		wantSequence   []string
+		wantStatements []string
+	}{
+		{
+			name:           "Up",
+			current:        database.NilVersion,
+			op:             OperationData{ID: operationUp},
+			wantVersion:    3,
+			wantSequence:   []string{upUsers, upOrders},
+			wantStatements: []string{"BEGIN", backfillNames, "COMMIT"},
+		},
+		{
+			name:           "Down",
+			current:        3,
+			op:             OperationData{ID: operationDown},
+			wantVersion:    2,
+			wantSequence:   []string{},
+			wantStatements: []string{"BEGIN", clearNames, "COMMIT"},
+		},
+		{
+			name:           "Failed",
+			current:        2,
+			op:             OperationData{ID: operationUp},
+			err:            errFailed,
+			wantErr:        errFailed,
+			wantVersion:    3,
+			wantDirty:      true,
+			wantSequence:   []string{},
+			wantStatements: []string{"BEGIN", "ROLLBACK"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &sqltest.DB{}
+			runner, db := newStubRunner(t, withBackfill(t, fake, tt.err)...)
+			require.NoError(t, db.SetVersion(tt.current, false))
+
+			err := runner.Run(tt.op)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+			assert.Equal(t, tt.wantVersion, db.CurrentVersion)
+			assert.Equal(t, tt.wantDirty, db.IsDirty)
+			assert.Equal(t, tt.wantSequence, db.MigrationSequence)
+			assert.Equal(t, tt.wantStatements, fake.Queries())
+		})
+	}
+}
@@ -0,0 +1,480 @@
+package migration
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	"gorm.io/gorm/schema"
+)
+
+// Rule identifies a kind of dangerous operation reported by Lint.
+type Rule string
+
+// Rules checked by Lint.
+const (
+	// RuleNotNullWithoutDefault reports the columns added as NOT NULL without a default,
+	// which fails on a non-empty table.
+	RuleNotNullWithoutDefault Rule = "not-null-without-default"
+
+	// RuleIndexNotConcurrent reports the indexes created or dropped without CONCURRENTLY,
+	// which blocks writes to the table until the index is built.
+	RuleIndexNotConcurrent Rule = "index-not-concurrent"
+
+	// RuleColumnTypeChange reports the column type changes, which may rewrite the whole table
+	// holding an exclusive lock.
+	RuleColumnTypeChange Rule = "column-type-change"
+
+	// RuleDropColumnInUse reports the dropped columns which are still used by the models given by WithModels
+	// or WithUsedColumns, so the running application fails until it's redeployed.
+	RuleDropColumnInUse Rule = "drop-column-in-use"
+
+	// RuleMissingDown reports the up migrations without a down migration.
+	RuleMissingDown Rule = "missing-down"
+)
+
+// lintIgnoreDirective in a comment of a statement suppresses the given rules for it,
+// e.g. "-- lint:ignore index-not-concurrent,column-type-change".
+const lintIgnoreDirective = "lint:ignore"
+
+// Finding is a dangerous operation found by Lint.
+type Finding struct {
+	// File is the path of the migration file: its directory in the file system or on the disk joined with its name.
+	File string
+
+	// Line is the line where the statement starts, it's 0 for the findings about the whole file.
+	Line int
+
+	Rule    Rule
+	Message string
+}
+
+// String returns the finding in the "file:line: rule: message" format.
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", f.File, f.Line, f.Rule, f.Message)
+}
+
+type lintConfig struct {
+	// usedColumns contains the used columns by table.
+	usedColumns map[string]map[string]bool
+	err         error
+}
+
+// LintOption represents a function that configures Lint.
+type LintOption func(cfg *lintConfig)
+
+// WithUsedColumns declares the columns of the table still used by the application,
+// so that dropping them is reported by RuleDropColumnInUse.
+func WithUsedColumns(table string, columns ...string) LintOption {
+	return func(cfg *lintConfig) {
+		table = normalizeIdentifier(table)
+		if cfg.usedColumns[table] == nil {
+			cfg.usedColumns[table] = make(map[string]bool)
+		}
+		for _, column := range columns {
+			cfg.usedColumns[table][normalizeIdentifier(column)] = true
+		}
+	}
+}
+
+// WithModels declares the tables and columns of the gorm models as still used by the application,
+// so that dropping them is reported by RuleDropColumnInUse. The default gorm naming strategy is assumed.
+func WithModels(models ...any) LintOption {
+	return func(cfg *lintConfig) {
+		cache := &sync.Map{}
+		for _, model := range models {
+			s, err := schema.Parse(model, cache, schema.NamingStrategy{})
+			if err != nil {
+				cfg.err = fmt.Errorf("parse model %T: %w", model, err)
+				return
+			}
+
+			WithUsedColumns(s.Table, s.DBNames...)(cfg)
+		}
+	}
+}
+
+func newLintConfig(opts []LintOption) (*lintConfig, error) {
+	cfg := &lintConfig{usedColumns: make(map[string]map[string]bool)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+
+	return cfg, nil
+}
+
+// Lint analyzes the SQL migration files in the directory dir of the file system and reports the operations
+// which are dangerous for a running application: see the Rule constants. A rule can be suppressed for
+// a statement with the "lint:ignore <rule>[,<rule>]" directive in a comment before or inside it.
+// The statements on the tables created in the same file are not reported, since the tables are empty.
+// For the migrations on the disk use os.DirFS(dir) and ".".
+func Lint(fsys fs.FS, dir string, opts ...LintOption) ([]Finding, error) {
+	cfg, err := newLintConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.lint([]fsLocation{{fsys: fsys, dir: dir, name: dir}})
+}
+
+// Lint analyzes the SQL migration files the Runner applies, i.e. of the directory set by WithFilesDir
+// and the file systems added by WithFS, like the package Lint function. The Go migrations are skipped.
+func (m *Runner) Lint(opts ...LintOption) ([]Finding, error) {
+	cfg, err := newLintConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.lint(m.locations)
+}
+
+// lint reports the findings of the migration files in the locations.
+// A down file may be in another location than its up file.
+func (cfg *lintConfig) lint(locations []fsLocation) ([]Finding, error) {
+	var (
+		findings []Finding
+		ups      = make(map[uint]string)
+		downs    = make(map[uint]bool)
+	)
+	for _, location := range locations {
+		entries, err := fs.ReadDir(location.fsys, location.dir)
+		if err != nil {
+			return nil, fmt.Errorf("read migrations dir %s: %w", location.name, err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || path.Ext(entry.Name()) != "."+migrationExt {
+				continue
+			}
+
+			m, err := source.DefaultParse(entry.Name())
+			if err != nil {
+				continue
+			}
+
+			file := path.Join(location.name, entry.Name())
+			if m.Direction == source.Down {
+				downs[m.Version] = true
+				continue
+			}
+			ups[m.Version] = file
+
+			body, err := fs.ReadFile(location.fsys, path.Join(location.dir, entry.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("read migration %s: %w", file, err)
+			}
+
+			for _, finding := range cfg.lintSQL(string(body)) {
+				finding.File = file
+				findings = append(findings, finding)
+			}
+		}
+	}
+	for version, file := range ups {
+		if !downs[version] {
+			findings = append(findings, Finding{
+				File:    file,
+				Rule:    RuleMissingDown,
+				Message: "migration has no down file, so it can't be rolled back",
+			})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+
+	return findings, nil
+}
+
+// TestingT is the subset of testing.TB used by AssertSafeMigrations.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// AssertSafeMigrations runs Lint in a test and reports every finding as a test error.
+// It returns true if there are no findings.
+func AssertSafeMigrations(t TestingT, fsys fs.FS, dir string, opts ...LintOption) bool {
+	t.Helper()
+
+	findings, err := Lint(fsys, dir, opts...)
+	if err != nil {
+		t.Errorf("lint migrations: %v", err)
+		return false
+	}
+
+	for _, finding := range findings {
+		t.Errorf("%s", finding)
+	}
+	return len(findings) == 0
+}
+
+var (
+	createTableRe = regexp.MustCompile(`(?i)^CREATE (?:(?:GLOBAL |LOCAL )?(?:TEMP|TEMPORARY|UNLOGGED) )?TABLE ` +
+		`(?:IF NOT EXISTS )?([^\s(]+)`)
+	createIndexRe = regexp.MustCompile(`(?i)^CREATE (?:UNIQUE )?INDEX (CONCURRENTLY )?.*?\bON (?:ONLY )?([^\s(]+)`)
+	dropIndexRe   = regexp.MustCompile(`(?i)^DROP INDEX (CONCURRENTLY )?`)
+	alterTableRe  = regexp.MustCompile(`(?i)^ALTER TABLE (?:IF EXISTS )?(?:ONLY )?(\S+) (.+)$`)
+
+	addColumnRe   = regexp.MustCompile(`(?i)^ADD (COLUMN )?(?:IF NOT EXISTS )?(\S+) (.*)$`)
+	alterTypeRe   = regexp.MustCompile(`(?i)^ALTER (?:COLUMN )?(\S+) (?:SET DATA )?TYPE `)
+	dropColumnRe  = regexp.MustCompile(`(?i)^DROP (COLUMN )?(?:IF EXISTS )?(\S+)`)
+	notNullRe     = regexp.MustCompile(`(?i)\bNOT NULL\b`)
+	hasDefaultRe  = regexp.MustCompile(`(?i)\b(?:DEFAULT|GENERATED)\b`)
+	constraintsRe = regexp.MustCompile(`(?i)^(?:CONSTRAINT|PRIMARY|UNIQUE|FOREIGN|CHECK|EXCLUDE)$`)
+)
+
+// lintSQL reports the findings of the statements in the migration file.
+func (cfg *lintConfig) lintSQL(body string) []Finding {
+	var (
+		findings []Finding
+		created  = make(map[string]bool)
+	)
+
+	for _, stmt := range splitStatements(body) {
+		report := func(rule Rule, format string, args ...any) {
+			if !stmt.ignore[rule] {
+				findings = append(findings, Finding{Line: stmt.line, Rule: rule, Message: fmt.Sprintf(format, args...)})
+			}
+		}
+
+		if match := createTableRe.FindStringSubmatch(stmt.text); match != nil {
+			created[normalizeIdentifier(match[1])] = true
+			continue
+		}
+
+		if match := createIndexRe.FindStringSubmatch(stmt.text); match != nil {
+			if match[1] == "" && !created[normalizeIdentifier(match[2])] {
+				report(RuleIndexNotConcurrent, "create the index CONCURRENTLY in a separate migration file")
+			}
+			continue
+		}
+
+		if match := dropIndexRe.FindStringSubmatch(stmt.text); match != nil {
+			if match[1] == "" {
+				report(RuleIndexNotConcurrent, "drop the index CONCURRENTLY in a separate migration file")
+			}
+			continue
+		}
+
+		match := alterTableRe.FindStringSubmatch(stmt.text)
+		if match == nil {
+			continue
+		}
+
+		table := normalizeIdentifier(match[1])
+		if created[table] {
+			continue
+		}
+
+		for _, action := range splitTopLevel(match[2], ',') {
+			cfg.lintAlterAction(table, action, report)
+		}
+	}
+
+	return findings
+}
+
+func (cfg *lintConfig) lintAlterAction(table, action string, report func(rule Rule, format string, args ...any)) {
+	if match := addColumnRe.FindStringSubmatch(action); match != nil {
+		// ADD CONSTRAINT, ADD PRIMARY KEY etc. don't add columns
+		if match[1] == "" && constraintsRe.MatchString(match[2]) {
+			return
+		}
+
+		if notNullRe.MatchString(match[3]) && !hasDefaultRe.MatchString(match[3]) {
+			report(RuleNotNullWithoutDefault,
+				"column %s.%s is added as NOT NULL without DEFAULT, which fails if the table isn't empty",
+				table, normalizeIdentifier(match[2]))
+		}
+		return
+	}
+
+	if match := alterTypeRe.FindStringSubmatch(action); match != nil {
+		report(RuleColumnTypeChange,
+			"changing the type of column %s.%s may rewrite the table holding an exclusive lock",
+			table, normalizeIdentifier(match[1]))
+		return
+	}
+
+	if match := dropColumnRe.FindStringSubmatch(action); match != nil {
+		// DROP CONSTRAINT etc. don't drop columns
+		if match[1] == "" && constraintsRe.MatchString(match[2]) {
+			return
+		}
+
+		column := normalizeIdentifier(match[2])
+		if cfg.usedColumns[table][column] {
+			report(RuleDropColumnInUse,
+				"column %s.%s is still used by the application, stop using it before dropping", table, column)
+		}
+	}
+}
+
+type statement struct {
+	// text is the statement without comments and with whitespace collapsed.
+	text string
+	line int
+
+	ignore map[Rule]bool
+}
+
+// splitStatements splits the SQL script into statements, skipping comments and respecting quotes
+// and dollar-quoted strings. The lint:ignore directives of the comments are attached to the statements.
+//
+//nolint:gocognit,cyclop // a single pass state machine is easier to follow than split functions
+func splitStatements(body string) []statement {
+	var (
+		statements []statement
+		current    strings.Builder
+		ignore     = make(map[Rule]bool)
+		line       = 1
+		startLine  = 0
+	)
+
+	flush := func() {
+		text := strings.Join(strings.Fields(current.String()), " ")
+		if text != "" {
+			statements = append(statements, statement{text: text, line: startLine, ignore: ignore})
+		}
+		current.Reset()
+		ignore = make(map[Rule]bool)
+		startLine = 0
+	}
+
+	write := func(s string) {
+		if startLine == 0 && strings.TrimSpace(s) != "" {
+			startLine = line
+		}
+		current.WriteString(s)
+		line += strings.Count(s, "\n")
+	}
+
+	for i := 0; i < len(body); {
+		rest := body[i:]
+
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			parseIgnoreDirective(rest[:end], ignore)
+			i += end
+
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 4
+			}
+			comment := rest[:end]
+			parseIgnoreDirective(comment, ignore)
+			line += strings.Count(comment, "\n")
+			current.WriteByte(' ')
+			i += end
+
+		case rest[0] == '\'' || rest[0] == '"':
+			end := strings.IndexByte(rest[1:], rest[0])
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 2
+			}
+			write(rest[:end])
+			i += end
+
+		case rest[0] == '$':
+			tag := dollarTagRe.FindString(rest)
+			if tag == "" {
+				write(rest[:1])
+				i++
+				continue
+			}
+			end := strings.Index(rest[len(tag):], tag)
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 2 * len(tag)
+			}
+			write(rest[:end])
+			i += end
+
+		case rest[0] == ';':
+			flush()
+			i++
+
+		default:
+			write(rest[:1])
+			i++
+		}
+	}
+	flush()
+
+	return statements
+}
+
+var dollarTagRe = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+func parseIgnoreDirective(comment string, ignore map[Rule]bool) {
+	_, rules, found := strings.Cut(comment, lintIgnoreDirective)
+	if !found {
+		return
+	}
+
+	rules = strings.TrimSuffix(strings.TrimSpace(rules), "*/")
+	for _, rule := range strings.FieldsFunc(rules, func(r rune) bool { return r == ',' || r == ' ' }) {
+		ignore[Rule(rule)] = true
+	}
+}
+
+// splitTopLevel splits s by the separator outside of parentheses.
+func splitTopLevel(s string, sep byte) []string {
+	var (
+		parts []string
+		depth int
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// normalizeIdentifier returns the unqualified identifier without quotes,
+// lower-cased unless it's quoted like Postgres does.
+func normalizeIdentifier(identifier string) string {
+	if i := strings.LastIndexByte(identifier, '.'); i >= 0 {
+		identifier = identifier[i+1:]
+	}
+
+	if unquoted, ok := strings.CutPrefix(identifier, `"`); ok {
+		return strings.TrimSuffix(unquoted, `"`)
+	}
+
+	return strings.ToLower(identifier)
+}
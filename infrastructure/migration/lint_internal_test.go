@@ -0,0 +1,42 @@
+package migration
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Runner_Lint(t *testing.T) {
+	disk := fstest.MapFS{
+		"000001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id bigint);")},
+		"000001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	}
+	embedded := fstest.MapFS{
+		"migrations/000003_index_users.up.sql": {Data: []byte("CREATE INDEX users_name_idx ON users (name);")},
+		"migrations/000004_add_email.up.sql":   {Data: []byte("ALTER TABLE users ADD COLUMN email text;")},
+		"migrations/000004_add_email.down.sql": {Data: []byte("ALTER TABLE users DROP COLUMN email;")},
+	}
+	// the down file of the embedded migration is kept on the disk
+	disk["000003_index_users.down.sql"] = &fstest.MapFile{Data: []byte("DROP INDEX CONCURRENTLY users_name_idx;")}
+
+	runner := &Runner{
+		locations: []fsLocation{
+			{fsys: disk, dir: ".", name: "dbmigrations"},
+			{fsys: embedded, dir: "migrations", name: "migrations"},
+		},
+		goMigrations: []goMigration{{
+			version: 2,
+			name:    "backfill_users",
+			up:      func(context.Context, *goDriver) error { return nil },
+		}},
+	}
+
+	findings, err := runner.Lint()
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "migrations/000003_index_users.up.sql", findings[0].File)
+	assert.Equal(t, RuleIndexNotConcurrent, findings[0].Rule)
+}
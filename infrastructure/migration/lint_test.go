@@ -0,0 +1,162 @@
+package migration_test
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/migration"
+)
+
+type user struct {
+	ID    int64
+	Email string
+	Name  string
+}
+
+func Test_Lint(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		opts []migration.LintOption
+		want []migration.Finding
+	}{
+		{
+			name: "Safe statements",
+			sql: `CREATE TABLE orders (id bigint PRIMARY KEY, user_id bigint NOT NULL);
+CREATE INDEX orders_user_id_idx ON orders (user_id);
+ALTER TABLE users ADD COLUMN age int, ADD COLUMN active boolean NOT NULL DEFAULT true;
+ALTER TABLE users ADD CONSTRAINT users_age_check CHECK (age > 0) NOT VALID;
+CREATE INDEX CONCURRENTLY users_age_idx ON users (age);
+INSERT INTO events (payload) VALUES ('ALTER TABLE users DROP COLUMN email; CREATE INDEX ON users (email)');`,
+			opts: []migration.LintOption{migration.WithModels(&user{})},
+		},
+		{
+			name: "Not null without default",
+			sql: `-- add the required column
+ALTER TABLE users
+	ADD COLUMN age int,
+	ADD COLUMN country text NOT NULL;`,
+			want: []migration.Finding{{
+				Line:    2,
+				Rule:    migration.RuleNotNullWithoutDefault,
+				Message: "column users.country is added as NOT NULL without DEFAULT, which fails if the table isn't empty",
+			}},
+		},
+		{
+			name: "Non-concurrent index",
+			sql: `CREATE UNIQUE INDEX users_email_key ON public.users USING btree (lower(email));
+
+DROP INDEX users_name_idx;`,
+			want: []migration.Finding{
+				{
+					Line:    1,
+					Rule:    migration.RuleIndexNotConcurrent,
+					Message: "create the index CONCURRENTLY in a separate migration file",
+				},
+				{
+					Line:    3,
+					Rule:    migration.RuleIndexNotConcurrent,
+					Message: "drop the index CONCURRENTLY in a separate migration file",
+				},
+			},
+		},
+		{
+			name: "Column type change",
+			sql:  `ALTER TABLE "users" ALTER COLUMN "name" TYPE varchar(100);`,
+			want: []migration.Finding{{
+				Line:    1,
+				Rule:    migration.RuleColumnTypeChange,
+				Message: "changing the type of column users.name may rewrite the table holding an exclusive lock",
+			}},
+		},
+		{
+			name: "Drop column in use",
+			sql: `ALTER TABLE users DROP COLUMN IF EXISTS legacy_id, DROP COLUMN email;
+ALTER TABLE orders DROP COLUMN note, DROP CONSTRAINT orders_note_check;`,
+			opts: []migration.LintOption{
+				migration.WithModels(&user{}),
+				migration.WithUsedColumns("orders", "note"),
+			},
+			want: []migration.Finding{
+				{
+					Line:    1,
+					Rule:    migration.RuleDropColumnInUse,
+					Message: "column users.email is still used by the application, stop using it before dropping",
+				},
+				{
+					Line:    2,
+					Rule:    migration.RuleDropColumnInUse,
+					Message: "column orders.note is still used by the application, stop using it before dropping",
+				},
+			},
+		},
+		{
+			name: "Ignored rules",
+			sql: `-- lint:ignore index-not-concurrent
+CREATE INDEX users_name_idx ON users (name);
+ALTER TABLE users /* lint:ignore column-type-change */ ALTER COLUMN name TYPE text;
+DO $body$ BEGIN ALTER TABLE users ALTER COLUMN name TYPE text; END $body$;`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"migrations/000001_test.up.sql":   {Data: []byte(tt.sql)},
+				"migrations/000001_test.down.sql": {Data: []byte("SELECT 1;")},
+			}
+
+			for i := range tt.want {
+				tt.want[i].File = "migrations/000001_test.up.sql"
+			}
+
+			findings, err := migration.Lint(fsys, "migrations", tt.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, findings)
+		})
+	}
+}
+
+func Test_Lint_MissingDown(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id bigint);")},
+		"000001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"000002_add_name.up.sql":       {Data: []byte("ALTER TABLE users ADD COLUMN name text;")},
+		"000003_add_indexes.up.json":   {Data: []byte("[]")},
+	}
+
+	findings, err := migration.Lint(fsys, ".")
+	require.NoError(t, err)
+	assert.Equal(t, []migration.Finding{{
+		File:    "000002_add_name.up.sql",
+		Rule:    migration.RuleMissingDown,
+		Message: "migration has no down file, so it can't be rolled back",
+	}}, findings)
+}
+
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func Test_AssertSafeMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000001_add_index.up.sql": {Data: []byte("\nCREATE INDEX users_name_idx ON users (name);")},
+	}
+
+	rt := &recordingT{}
+	assert.False(t, migration.AssertSafeMigrations(rt, fsys, "."))
+	assert.Equal(t, []string{
+		"000001_add_index.up.sql:0: missing-down: migration has no down file, so it can't be rolled back",
+		"000001_add_index.up.sql:2: index-not-concurrent: create the index CONCURRENTLY in a separate migration file",
+	}, rt.errors)
+}
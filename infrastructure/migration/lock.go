@@ -0,0 +1,215 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/lock"
+)
+
+const (
+	defaultLockName   = "go-lib:migrations"
+	lockRetryInterval = time.Second
+	waitInterval      = time.Second
+	holderTimeout     = 5 * time.Second
+)
+
+var (
+	// ErrLockTimeout is returned when the migrations lock isn't acquired within the timeout set by WithLock.
+	ErrLockTimeout = errors.New("timeout acquiring migrations lock")
+
+	// ErrMigrationFailed is returned by WaitForVersion when the database stays dirty
+	// while no instance holds the migrations lock, i.e. a migration has failed rather than is running.
+	ErrMigrationFailed = errors.New("migration failed")
+)
+
+// WithLock makes the Runner hold the Postgres advisory lock with the given name while running the operations
+// changing the database, so that only one of the concurrently started instances applies migrations
+// and the others wait for it to finish. If the lock isn't acquired within the timeout,
+// the operation fails with ErrLockTimeout describing the session holding the lock.
+// If the timeout is zero, the lock is awaited indefinitely.
+// If the name is empty, "go-lib:migrations" will be used. The lock is taken on a separate connection
+// opened with the same dsn, so the dsn must be a Postgres connection string.
+func WithLock(name string, timeout time.Duration) Option {
+	return func(runner *Runner) {
+		if name == "" {
+			name = defaultLockName
+		}
+
+		runner.lockName = name
+		runner.lockTimeout = timeout
+	}
+}
+
+// LockHolder returns the session holding the migrations lock, or nil if the lock is free.
+// It requires the lock to be enabled with WithLock.
+func (m *Runner) LockHolder(ctx context.Context) (*lock.Holder, error) {
+	if m.locker == nil {
+		return nil, errors.New("migrations lock is not enabled")
+	}
+
+	return m.locker.Holder(ctx, m.lockName)
+}
+
+// WaitForVersion waits until the migrations up to the given version are applied, e.g. by another instance,
+// so that the application doesn't become ready before its database schema.
+// If the version is 0, the latest version of the migration files is awaited.
+// The dirty state is awaited as well, since it's also reported while a migration is running.
+// If the lock is enabled with WithLock, it fails with ErrMigrationFailed as soon as the database is dirty
+// while the lock is free. Otherwise a failed migration can't be told from a running one,
+// so the context must have a deadline not to wait forever.
+func (m *Runner) WaitForVersion(ctx context.Context, version uint) error {
+	if version == 0 {
+		migrations := m.src.list()
+		if len(migrations) == 0 {
+			return nil
+		}
+		version = migrations[len(migrations)-1].Version
+	}
+
+	ticker := time.NewTicker(waitInterval)
+	defer ticker.Stop()
+
+	for {
+		current, dirty, err := m.mgr.Version()
+		if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+			return fmt.Errorf("getting current version: %w", err)
+		}
+		if err == nil && !dirty && current >= version {
+			return nil
+		}
+
+		if err == nil && dirty && m.locker != nil {
+			failed, checkErr := m.migrationFailed(ctx)
+			if checkErr != nil {
+				return checkErr
+			}
+			if failed {
+				return fmt.Errorf("waiting for version %d: %w: version %d is dirty and lock %s is free",
+					version, ErrMigrationFailed, current, m.lockName)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for version %d, current version: %d, dirty: %v: %w",
+				version, current, dirty, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// migrationFailed reports whether the database is dirty while no instance holds the migrations lock.
+// The version is checked again after the lock, since the running migration may have just finished.
+func (m *Runner) migrationFailed(ctx context.Context) (bool, error) {
+	holder, err := m.locker.Holder(ctx, m.lockName)
+	if err != nil {
+		return false, fmt.Errorf("getting migrations lock holder: %w", err)
+	}
+	if holder != nil {
+		return false, nil
+	}
+
+	_, dirty, err := m.mgr.Version()
+	if err != nil {
+		return false, fmt.Errorf("getting current version: %w", err)
+	}
+
+	return dirty, nil
+}
+
+// acquireLock tries to acquire the migrations lock until it succeeds or the timeout expires.
+func (m *Runner) acquireLock() (*lock.Lease, error) {
+	ctx := context.Background()
+	if m.lockTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.lockTimeout)
+		defer cancel()
+	}
+
+	ticker := time.NewTicker(lockRetryInterval)
+	defer ticker.Stop()
+
+	for logged := false; ; {
+		lease, acquired, err := m.locker.TryLock(ctx, m.lockName)
+		if err != nil && ctx.Err() == nil {
+			return nil, fmt.Errorf("acquiring migrations lock: %w", err)
+		}
+		if acquired {
+			return lease, nil
+		}
+
+		if !logged && err == nil {
+			m.logger.Info("waiting for migrations lock " + m.describeHolder())
+			logged = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w %s", ErrLockTimeout, m.describeHolder())
+		case <-ticker.C:
+		}
+	}
+}
+
+// describeHolder returns the description of the session holding the migrations lock, if it can be found.
+func (m *Runner) describeHolder() string {
+	ctx, cancel := context.WithTimeout(context.Background(), holderTimeout)
+	defer cancel()
+
+	holder, err := m.locker.Holder(ctx, m.lockName)
+	if err != nil {
+		m.logger.Error(fmt.Sprintf("getting migrations lock holder: %v", err))
+		return m.lockName
+	}
+	if holder == nil {
+		return m.lockName
+	}
+
+	return fmt.Sprintf("%s held by %s", m.lockName, holder)
+}
+
+// openLockDB opens the connection pool for the migrations lock.
+// The golang-migrate parameters (x-*) are removed from the dsn, since Postgres doesn't know them,
+// and the application name identifies the host holding the lock unless it's set in the dsn.
+func openLockDB(dsn string) (*sql.DB, error) {
+	connCfg, err := pgx.ParseConfig(stripMigrateParams(dsn))
+	if err != nil {
+		return nil, fmt.Errorf("parse dsn: %w", err)
+	}
+
+	if _, ok := connCfg.RuntimeParams["application_name"]; !ok {
+		hostname, _ := os.Hostname()
+		connCfg.RuntimeParams["application_name"] = strings.TrimSpace("migrations " + hostname)
+	}
+
+	return stdlib.OpenDB(*connCfg), nil
+}
+
+// stripMigrateParams removes the golang-migrate parameters (x-*) from the dsn.
+func stripMigrateParams(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return dsn
+	}
+
+	query := u.Query()
+	for key := range query {
+		if strings.HasPrefix(key, "x-") {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
@@ -0,0 +1,230 @@
+package migration
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/lock"
+	"github.com/kodenkai-labs/go-lib/internal/sqltest"
+)
+
+var stubMigrations = fstest.MapFS{
+	"1_create_users.up.sql":    {Data: []byte(upUsers)},
+	"1_create_users.down.sql":  {Data: []byte(downUsers)},
+	"2_create_orders.up.sql":   {Data: []byte(upOrders)},
+	"2_create_orders.down.sql": {Data: []byte(downOrders)},
+}
+
+// newStubRunner returns a Runner of the stub database with the migrations, and the stub.
+func newStubRunner(t *testing.T, opts ...Option) (*Runner, *stub.Stub) {
+	t.Helper()
+
+	runner, err := NewRunner("stub://", append([]Option{WithFS(stubMigrations, ".")}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = runner.Close()
+	})
+
+	db := runner.db
+	if driver, ok := db.(*goDriver); ok {
+		db = driver.Driver
+	}
+	return runner, db.(*stub.Stub)
+}
+
+// newLockDB returns a fake database emulating a single session-level advisory lock and its holder.
+func newLockDB() *sqltest.DB {
+	var (
+		mu     sync.Mutex
+		holder int
+	)
+
+	return &sqltest.DB{
+		Exec: func(conn int, query string, _ []any) (driver.Result, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if strings.Contains(query, "pg_advisory_unlock") && holder == conn {
+				holder = 0
+			}
+			return driver.RowsAffected(1), nil
+		},
+		Query: func(conn int, query string, _ []any) (driver.Rows, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			columns := []string{"pid", "application_name", "client_addr", "backend_start"}
+			switch {
+			case strings.Contains(query, "pg_try_advisory_lock"):
+				acquired := holder == 0 || holder == conn
+				if acquired {
+					holder = conn
+				}
+				return sqltest.NewRows([]string{"value"}, []driver.Value{acquired}), nil
+			case holder == 0:
+				return sqltest.NewRows(columns), nil
+			default:
+				return sqltest.NewRows(columns, []driver.Value{int64(holder), "migrations", "", time.Now()}), nil
+			}
+		},
+		Close: func(conn int) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if holder == conn {
+				holder = 0
+			}
+		},
+	}
+}
+
+// enableLock enables the migrations lock of the runner on the fake database, like WithLock does.
+func enableLock(runner *Runner, db *sqltest.DB, timeout time.Duration) {
+	runner.lockName = defaultLockName
+	runner.lockTimeout = timeout
+	runner.locker = lock.NewLockerFromDB(db.Open())
+}
+
+// holdLock takes the migrations lock by another session until the test ends.
+func holdLock(t *testing.T, db *sqltest.DB) *lock.Lease {
+	t.Helper()
+
+	lease, acquired, err := lock.NewLockerFromDB(db.Open()).TryLock(context.Background(), defaultLockName)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	t.Cleanup(func() {
+		_ = lease.Release(context.Background())
+	})
+
+	return lease
+}
+
+func Test_Runner_WaitForVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  uint
+		current  int
+		dirty    bool
+		lock     bool
+		lockHeld bool
+		wantErr  error
+	}{
+		{
+			name:    "Latest version applied",
+			current: 2,
+		},
+		{
+			name:    "Requested version applied",
+			version: 1,
+			current: 2,
+		},
+		{
+			name:    "Pending",
+			current: 1,
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:    "Dirty without lock",
+			current: 2,
+			dirty:   true,
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:     "Dirty while migration is running",
+			current:  2,
+			dirty:    true,
+			lock:     true,
+			lockHeld: true,
+			wantErr:  context.DeadlineExceeded,
+		},
+		{
+			name:    "Dirty with free lock",
+			current: 2,
+			dirty:   true,
+			lock:    true,
+			wantErr: ErrMigrationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, db := newStubRunner(t)
+			require.NoError(t, db.SetVersion(tt.current, tt.dirty))
+
+			if tt.lock {
+				lockDB := newLockDB()
+				enableLock(runner, lockDB, time.Second)
+				if tt.lockHeld {
+					holdLock(t, lockDB)
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			err := runner.WaitForVersion(ctx, tt.version)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_Runner_Run_Lock(t *testing.T) {
+	t.Run("Free lock", func(t *testing.T) {
+		runner, db := newStubRunner(t)
+		lockDB := newLockDB()
+		enableLock(runner, lockDB, time.Second)
+
+		require.NoError(t, runner.Run(OperationData{ID: operationUp}))
+		assert.Equal(t, 2, db.CurrentVersion)
+		assert.Len(t, lockDB.QueriesWithPrefix("SELECT pg_try_advisory_lock"), 1)
+		assert.Len(t, lockDB.QueriesWithPrefix("SELECT pg_advisory_unlock"), 1, "the lock is released")
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		runner, db := newStubRunner(t)
+		lockDB := newLockDB()
+		enableLock(runner, lockDB, 50*time.Millisecond)
+		holdLock(t, lockDB)
+
+		err := runner.Run(OperationData{ID: operationUp})
+		assert.ErrorIs(t, err, ErrLockTimeout)
+		assert.Contains(t, err.Error(), "held by pid")
+		assert.Empty(t, db.MigrationSequence, "migrations aren't applied without the lock")
+	})
+
+	t.Run("Zero timeout waits for the lock", func(t *testing.T) {
+		runner, db := newStubRunner(t)
+		lockDB := newLockDB()
+		enableLock(runner, lockDB, 0)
+		lease := holdLock(t, lockDB)
+
+		time.AfterFunc(100*time.Millisecond, func() {
+			_ = lease.Release(context.Background())
+		})
+
+		require.NoError(t, runner.Run(OperationData{ID: operationUp}))
+		assert.Equal(t, 2, db.CurrentVersion)
+	})
+
+	t.Run("Read-only operation", func(t *testing.T) {
+		runner, _ := newStubRunner(t)
+		lockDB := newLockDB()
+		enableLock(runner, lockDB, 50*time.Millisecond)
+		holdLock(t, lockDB)
+
+		require.NoError(t, runner.Run(OperationData{ID: operationStatus}))
+	})
+}
@@ -1,39 +1,129 @@
 package migration
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"io/fs"
+	"net/url"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/lock"
+	"github.com/kodenkai-labs/go-lib/infrastructure/postgres"
 )
 
 // Supported migrate operations.
 const (
-	defaultFilesDir = "dbmigrations"
-	operationUp     = "up"
-	operationDown   = "down"
-	operationForce  = "force"
+	defaultFilesDir  = "dbmigrations"
+	operationUp      = "up"
+	operationDown    = "down"
+	operationGoto    = "goto"
+	operationForce   = "force"
+	operationDrop    = "drop"
+	operationStatus  = "status"
+	operationWait    = "wait"
+	operationRecover = "recover"
 )
 
+// ErrDropNotAllowed is returned by the "drop" operation when it's not explicitly allowed by OperationData.AllowDrop.
+var ErrDropNotAllowed = errors.New("drop is not allowed")
+
 type operationFn func(*Runner, OperationData) error
 
 var supportedOperations = map[string]operationFn{
-	operationUp:    runUp,
-	operationDown:  runDown,
-	operationForce: runForce,
+	operationUp:      runUp,
+	operationDown:    runDown,
+	operationGoto:    runGoto,
+	operationForce:   runForce,
+	operationDrop:    runDrop,
+	operationStatus:  runStatus,
+	operationWait:    runWait,
+	operationRecover: runRecover,
+}
+
+// readOnlyOperations don't change the database, so they don't take the migrations lock.
+var readOnlyOperations = map[string]bool{
+	operationStatus: true,
+	operationWait:   true,
 }
 
 // OperationData contains information about the migration operation to be performed.
 type OperationData struct {
 	ID           string
 	ForceVersion int
+
+	// Version is the target version of the "goto" and "wait" operations.
+	// It's required by "goto". If zero, "wait" awaits the latest version of the migration files.
+	Version uint
+
+	// Steps is the number of migrations applied by "up" or rolled back by "down".
+	// If zero, "up" applies all new migrations and "down" rolls back the latest applied migration only.
+	Steps int
+
+	// AllowDrop must be set to run the "drop" operation, which deletes everything in the database.
+	AllowDrop bool
+
+	// Timeout limits the waiting of the "wait" operation, it waits infinitely if zero.
+	// It's required unless the lock is enabled with WithLock, see Runner.WaitForVersion.
+	Timeout time.Duration
+
+	// DryRun makes the "recover" operation only log what it would do without changing the database.
+	DryRun bool
+}
+
+// MigrationStatus describes a migration file and whether it's applied to the database.
+type MigrationStatus struct {
+	Version    uint
+	Identifier string
+	Applied    bool
+
+	// Dirty is set for the current version if its migration failed and the database must be fixed manually.
+	Dirty bool
+}
+
+// State returns the state of the migration: "dirty", "applied" or "pending".
+func (s MigrationStatus) State() string {
+	switch {
+	case s.Dirty:
+		return "dirty"
+	case s.Applied:
+		return "applied"
+	default:
+		return "pending"
+	}
 }
 
 // Runner is responsible for managing and running database migrations.
 type Runner struct {
 	mgr      *migrate.Migrate
+	src      *multiSource
+	db       database.Driver
 	filesDir string
 	logger   logger
+
+	// useFilesDir is set when the files directory is configured explicitly.
+	useFilesDir bool
+	fsLocations []fsLocation
+	// locations are all the locations of the migration files, including the files directory.
+	locations []fsLocation
+
+	lockName    string
+	lockTimeout time.Duration
+	locker      *lock.Locker
+	lockDB      *sql.DB
+
+	goMigrations []goMigration
+	getter       *postgres.DBGetter
+	mongoDB      *mongo.Database
+	// ownGetter and ownMongoDB are set when the connections are opened by the Runner,
+	// so they must be closed with it.
+	ownGetter  bool
+	ownMongoDB bool
 }
 
 // Option represents a function that configures a Runner.
@@ -48,10 +138,21 @@ func WithLogger(logger logger) Option {
 }
 
 // WithFilesDir sets a custom directory containing migration files for the Runner.
-// If not provided, the default directory "dbmigrations" will be used.
+// If neither WithFilesDir nor WithFS is provided, the default directory "dbmigrations" will be used.
 func WithFilesDir(filesDir string) Option {
 	return func(runner *Runner) {
 		runner.filesDir = filesDir
+		runner.useFilesDir = true
+	}
+}
+
+// WithFS adds the migration files from the directory dir of the file system, e.g. embedded with //go:embed,
+// so the binary doesn't depend on the migration files on the disk.
+// It can be provided several times and combined with WithFilesDir: the migrations of all the sources
+// are ordered by version, so the versions must be unique across the sources.
+func WithFS(fsys fs.FS, dir string) Option {
+	return func(runner *Runner) {
+		runner.fsLocations = append(runner.fsLocations, fsLocation{fsys: fsys, dir: dir, name: dir})
 	}
 }
 
@@ -66,18 +167,65 @@ func NewRunner(dsn string, opts ...Option) (*Runner, error) {
 		opt(runner)
 	}
 
-	mgr, err := migrate.New("file://"+runner.filesDir, dsn)
+	locations := runner.fsLocations
+	if runner.useFilesDir || len(locations) == 0 {
+		locations = append([]fsLocation{dirLocation(runner.filesDir)}, locations...)
+	}
+
+	src, err := newMultiSource(locations)
 	if err != nil {
+		return nil, fmt.Errorf("reading migration sources: %w", err)
+	}
+	if err = src.addGoMigrations(runner.goMigrations); err != nil {
+		return nil, fmt.Errorf("adding go migrations: %w", err)
+	}
+
+	db, err := database.Open(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+
+	if len(runner.goMigrations) > 0 {
+		if err = runner.connectGoTargets(dsn); err != nil {
+			_ = db.Close()
+			_ = runner.closeGoTargets()
+			return nil, fmt.Errorf("connecting go migrations database: %w", err)
+		}
+
+		db = &goDriver{Driver: db, src: src, getter: runner.getter, mongoDB: runner.mongoDB}
+	}
+
+	mgr, err := migrate.NewWithInstance(sourceName, src, databaseName(dsn), db)
+	if err != nil {
+		_ = db.Close()
 		return nil, fmt.Errorf("creating Migrate object: %w", err)
 	}
 
 	mgr.Log = toMigrationsLogger(runner.logger)
 	runner.mgr = mgr
+	runner.src = src
+	runner.db = db
+	runner.locations = locations
+
+	if runner.lockName != "" {
+		lockDB, err := openLockDB(dsn)
+		if err != nil {
+			_, _ = mgr.Close()
+			return nil, fmt.Errorf("opening migrations lock connection: %w", err)
+		}
+
+		if runner.lockTimeout > 0 {
+			mgr.LockTimeout = runner.lockTimeout
+		}
+		runner.lockDB = lockDB
+		runner.locker = lock.NewLockerFromDB(lockDB)
+	}
 
 	return runner, nil
 }
 
 // Run executes the migration operation specified by the OperationData.
+// If the operation fails and leaves the database dirty, the failed migration is logged with its script.
 func (m *Runner) Run(operation OperationData) error {
 	operationName := operation.ID
 	operationFunc, found := supportedOperations[operationName]
@@ -85,25 +233,82 @@ func (m *Runner) Run(operation OperationData) error {
 		return fmt.Errorf("unsupported migration operation: %s", operationName)
 	}
 
+	if m.locker != nil && !readOnlyOperations[operationName] {
+		lease, err := m.acquireLock()
+		if err != nil {
+			return fmt.Errorf("operation %s failed: %w", operationName, err)
+		}
+		defer func() {
+			if err := lease.Release(context.Background()); err != nil {
+				m.logger.Error(fmt.Sprintf("releasing migrations lock: %v", err))
+			}
+		}()
+	}
+
 	if err := operationFunc(m, operation); err != nil {
+		// report the migration failed by the operation, the database is left dirty by it
+		m.logFailedMigration()
 		return fmt.Errorf("operation %s failed: %w", operationName, err)
 	}
 
 	return nil
 }
 
+// Close closes the database connections and the migration sources.
+func (m *Runner) Close() error {
+	srcErr, dbErr := m.mgr.Close()
+
+	var lockErr error
+	if m.lockDB != nil {
+		lockErr = m.lockDB.Close()
+	}
+
+	return errors.Join(srcErr, dbErr, lockErr, m.closeGoTargets())
+}
+
 // Version returns the current migration version, a dirty flag, and an error if any.
 func (m *Runner) Version() (uint, bool, error) {
 	return m.mgr.Version()
 }
 
+// Status lists all migration files ordered by version with their state in the database.
+func (m *Runner) Status() ([]MigrationStatus, error) {
+	current, dirty, err := m.mgr.Version()
+	applied := err == nil
+	if errors.Is(err, migrate.ErrNilVersion) {
+		err = nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting current version: %w", err)
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range m.src.list() {
+		statuses = append(statuses, MigrationStatus{
+			Version:    migration.Version,
+			Identifier: migration.Identifier,
+			Applied:    applied && migration.Version <= current,
+			Dirty:      dirty && migration.Version == current,
+		})
+	}
+
+	return statuses, nil
+}
+
 // runUp runs the "up" migration operation, applying new migrations to the database.
-func runUp(m *Runner, _ OperationData) error {
-	m.logger.Info("running migrate UP")
+// If Steps is set, only the given number of new migrations is applied.
+func runUp(m *Runner, op OperationData) error {
+	var err error
+	if op.Steps > 0 {
+		m.logger.Info(fmt.Sprintf("running migrate UP with STEPS=%d", op.Steps))
+		err = m.mgr.Steps(op.Steps)
+	} else {
+		m.logger.Info("running migrate UP")
+		err = m.mgr.Up()
+	}
 
-	err := m.mgr.Up()
 	if errors.Is(err, migrate.ErrNoChange) {
-		m.logger.Info("no new migrations found in: ", m.filesDir)
+		m.logger.Info("no new migrations found")
 		return nil
 	}
 	if err != nil {
@@ -112,18 +317,40 @@ func runUp(m *Runner, _ OperationData) error {
 	return nil
 }
 
-// runDown runs the "down" migration operation, rolling back the latest applied migration.
-func runDown(m *Runner, _ OperationData) error {
-	m.logger.Info(fmt.Sprintf("running migrate DOWN with STEPS=%d", 1))
+// runDown runs the "down" migration operation, rolling back the given number of the latest applied migrations.
+func runDown(m *Runner, op OperationData) error {
+	// rollback the latest applied migration only, unless the number of steps is given explicitly
+	steps := max(op.Steps, 1)
+
+	m.logger.Info(fmt.Sprintf("running migrate DOWN with STEPS=%d", steps))
 
-	// always rollback the latest applied migration only
-	err := m.mgr.Steps(-1)
+	err := m.mgr.Steps(-steps)
 	if err != nil {
 		return fmt.Errorf("running migrations DOWN failed: %w", err)
 	}
 	return nil
 }
 
+// runGoto runs the "goto" migration operation,
+// applying or rolling back migrations until the database is at the given version.
+func runGoto(m *Runner, op OperationData) error {
+	if op.Version == 0 {
+		return errors.New("goto requires a version, use down to roll back all migrations")
+	}
+
+	m.logger.Info(fmt.Sprintf("running GOTO with VERSION %d", op.Version))
+
+	err := m.mgr.Migrate(op.Version)
+	if errors.Is(err, migrate.ErrNoChange) {
+		m.logger.Info(fmt.Sprintf("database is already at version %d", op.Version))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("running migrations GOTO with VERSION %d failed: %w", op.Version, err)
+	}
+	return nil
+}
+
 // runForce runs the "force" migration operation,
 // forcibly setting the migration version without running the actual migrations.
 func runForce(m *Runner, op OperationData) error {
@@ -136,6 +363,62 @@ func runForce(m *Runner, op OperationData) error {
 	return nil
 }
 
+// runDrop runs the "drop" migration operation, deleting everything in the database.
+// It fails with ErrDropNotAllowed unless AllowDrop is set.
+func runDrop(m *Runner, op OperationData) error {
+	if !op.AllowDrop {
+		return ErrDropNotAllowed
+	}
+
+	m.logger.Info("running DROP")
+
+	if err := m.mgr.Drop(); err != nil {
+		return fmt.Errorf("running DROP failed: %w", err)
+	}
+	return nil
+}
+
+// runStatus runs the "status" migration operation, logging all migration files with their state.
+func runStatus(m *Runner, _ OperationData) error {
+	statuses, err := m.Status()
+	if err != nil {
+		return fmt.Errorf("getting migrations status: %w", err)
+	}
+
+	for _, status := range statuses {
+		m.logger.Info(fmt.Sprintf("%d %s: %s", status.Version, status.Identifier, status.State()))
+	}
+	return nil
+}
+
+// runWait runs the "wait" migration operation,
+// waiting until the migrations up to the given or the latest version are applied by another instance.
+func runWait(m *Runner, op OperationData) error {
+	m.logger.Info(fmt.Sprintf("running WAIT for VERSION %d", op.Version))
+
+	if op.Timeout <= 0 && m.locker == nil {
+		return errors.New("wait requires a timeout unless the migrations lock is enabled")
+	}
+
+	ctx := context.Background()
+	if op.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, op.Timeout)
+		defer cancel()
+	}
+
+	return m.WaitForVersion(ctx, op.Version)
+}
+
+// databaseName returns the name of the database driver, which is the scheme of the dsn.
+func databaseName(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return ""
+	}
+	return u.Scheme
+}
+
 type logger interface {
 	Info(args ...any)
 	Error(args ...any)
@@ -176,4 +459,4 @@ func (m *migrationsLogger) Info(args ...any) {
 
 func (m *migrationsLogger) Error(args ...any) {
 	m.logger.Error(args...)
-}
\ No newline at end of file
+}
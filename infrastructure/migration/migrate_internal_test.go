@@ -0,0 +1,182 @@
+package migration
+
+import (
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	upUsers    = "CREATE TABLE users (id bigint);"
+	upOrders   = "CREATE TABLE orders (id bigint);"
+	downUsers  = "DROP TABLE users;"
+	downOrders = "DROP TABLE orders;"
+)
+
+func Test_Runner_Run(t *testing.T) {
+	tests := []struct {
+		name         string
+		current      int
+		op           OperationData
+		wantErr      error
+		wantErrText  string
+		wantVersion  int
+		wantSequence []string
+	}{
+		{
+			name:         "Up",
+			current:      database.NilVersion,
+			op:           OperationData{ID: operationUp},
+			wantVersion:  2,
+			wantSequence: []string{upUsers, upOrders},
+		},
+		{
+			name:         "Up with steps",
+			current:      database.NilVersion,
+			op:           OperationData{ID: operationUp, Steps: 1},
+			wantVersion:  1,
+			wantSequence: []string{upUsers},
+		},
+		{
+			name:         "Up without new migrations",
+			current:      2,
+			op:           OperationData{ID: operationUp},
+			wantVersion:  2,
+			wantSequence: []string{},
+		},
+		{
+			name:         "Down rolls back the latest migration",
+			current:      2,
+			op:           OperationData{ID: operationDown},
+			wantVersion:  1,
+			wantSequence: []string{downOrders},
+		},
+		{
+			name:         "Down with steps",
+			current:      2,
+			op:           OperationData{ID: operationDown, Steps: 2},
+			wantVersion:  database.NilVersion,
+			wantSequence: []string{downOrders, downUsers},
+		},
+		{
+			name:         "Goto up",
+			current:      database.NilVersion,
+			op:           OperationData{ID: operationGoto, Version: 1},
+			wantVersion:  1,
+			wantSequence: []string{upUsers},
+		},
+		{
+			name:         "Goto down",
+			current:      2,
+			op:           OperationData{ID: operationGoto, Version: 1},
+			wantVersion:  1,
+			wantSequence: []string{downOrders},
+		},
+		{
+			name:         "Goto current version",
+			current:      1,
+			op:           OperationData{ID: operationGoto, Version: 1},
+			wantVersion:  1,
+			wantSequence: []string{},
+		},
+		{
+			name:         "Goto without version",
+			current:      2,
+			op:           OperationData{ID: operationGoto},
+			wantErrText:  "goto requires a version",
+			wantVersion:  2,
+			wantSequence: []string{},
+		},
+		{
+			name:         "Drop not allowed",
+			current:      2,
+			op:           OperationData{ID: operationDrop},
+			wantErr:      ErrDropNotAllowed,
+			wantVersion:  2,
+			wantSequence: []string{},
+		},
+		{
+			name:         "Drop allowed",
+			current:      2,
+			op:           OperationData{ID: operationDrop, AllowDrop: true},
+			wantVersion:  database.NilVersion,
+			wantSequence: []string{stub.DROP},
+		},
+		{
+			name:         "Unsupported operation",
+			current:      2,
+			op:           OperationData{ID: "unknown"},
+			wantErrText:  "unsupported migration operation",
+			wantVersion:  2,
+			wantSequence: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, db := newStubRunner(t)
+			require.NoError(t, db.SetVersion(tt.current, false))
+
+			err := runner.Run(tt.op)
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.wantErrText != "":
+				assert.ErrorContains(t, err, tt.wantErrText)
+			default:
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantVersion, db.CurrentVersion)
+			assert.Equal(t, tt.wantSequence, db.MigrationSequence)
+		})
+	}
+}
+
+func Test_Runner_Status(t *testing.T) {
+	tests := []struct {
+		name    string
+		current int
+		dirty   bool
+		want    []MigrationStatus
+	}{
+		{
+			name:    "Nothing applied",
+			current: database.NilVersion,
+			want: []MigrationStatus{
+				{Version: 1, Identifier: "create_users"},
+				{Version: 2, Identifier: "create_orders"},
+			},
+		},
+		{
+			name:    "Partially applied",
+			current: 1,
+			want: []MigrationStatus{
+				{Version: 1, Identifier: "create_users", Applied: true},
+				{Version: 2, Identifier: "create_orders"},
+			},
+		},
+		{
+			name:    "Dirty",
+			current: 2,
+			dirty:   true,
+			want: []MigrationStatus{
+				{Version: 1, Identifier: "create_users", Applied: true},
+				{Version: 2, Identifier: "create_orders", Applied: true, Dirty: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, db := newStubRunner(t)
+			require.NoError(t, db.SetVersion(tt.current, tt.dirty))
+
+			statuses, err := runner.Status()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, statuses)
+		})
+	}
+}
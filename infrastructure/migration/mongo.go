@@ -0,0 +1,75 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	golibmongo "github.com/kodenkai-labs/go-lib/infrastructure/mongo"
+)
+
+const mongoTimeout = 5 * time.Second
+
+// MongoMigrationFunc is a MongoDB migration implemented in Go, e.g. a backfill which can't be expressed
+// as database commands. Unlike GoMigrationFunc it doesn't run in a transaction,
+// since transactions require a replica set and can't create collections and indexes on older servers.
+type MongoMigrationFunc func(ctx context.Context, db *mongo.Database) error
+
+// WithMongoMigration registers the MongoDB migration implemented in Go.
+//
+// MongoDB targets are selected by the mongodb:// dsn with the database name in the path, which requires
+// the golang-migrate mongodb driver to be imported. Their migration files are JSON arrays of database commands,
+// e.g. 000001_create_users_indexes.up.json with [{"createIndexes": "users", "indexes": [...]}],
+// and the applied version is tracked in the schema_migrations collection
+// (configurable with the x-migrations-collection dsn parameter).
+// The Go migrations are applied in order of versions along with the files, so their versions must be unique.
+// The down function is optional and can be nil.
+// They run on the database provided by WithMongoDatabase or connected to the dsn.
+func WithMongoMigration(version uint, name string, up, down MongoMigrationFunc) Option {
+	return func(runner *Runner) {
+		runner.goMigrations = append(runner.goMigrations, goMigration{
+			version: version,
+			name:    name,
+			target:  targetMongo,
+			up:      mongoMigrationFunc(up),
+			down:    mongoMigrationFunc(down),
+		})
+	}
+}
+
+// WithMongoDatabase sets the database running the MongoDB Go migrations.
+// If not provided, the database of the dsn will be connected.
+func WithMongoDatabase(db *mongo.Database) Option {
+	return func(runner *Runner) {
+		runner.mongoDB = db
+	}
+}
+
+func mongoMigrationFunc(fn MongoMigrationFunc) migrationFunc {
+	if fn == nil {
+		return nil
+	}
+
+	return func(ctx context.Context, d *goDriver) error {
+		return fn(ctx, d.mongoDB)
+	}
+}
+
+// connectMongo connects to the database of the dsn without the golang-migrate parameters (x-*).
+func connectMongo(dsn string) (*mongo.Database, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimPrefix(u.Path, "/")
+	if name == "" {
+		return nil, errors.New("database name is missing in dsn")
+	}
+
+	return golibmongo.New(golibmongo.Config{URI: stripMigrateParams(dsn), Name: name}, golibmongo.DBModeWrite)
+}
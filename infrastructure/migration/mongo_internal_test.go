@@ -0,0 +1,89 @@
+package migration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newMongoDatabase returns a database of the client which isn't connected to any server,
+// the migrations under test only pass it through.
+func newMongoDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = client.Disconnect(context.Background())
+	})
+
+	return client.Database("app")
+}
+
+func Test_Runner_Run_MongoMigration(t *testing.T) {
+	tests := []struct {
+		name         string
+		current      int
+		op           OperationData
+		wantCalled   []string
+		wantVersion  int
+		wantSequence []string
+	}{
+		{
+			name:         "Up",
+			current:      database.NilVersion,
+			op:           OperationData{ID: operationUp},
+			wantCalled:   []string{"up"},
+			wantVersion:  3,
+			wantSequence: []string{upUsers, upOrders},
+		},
+		{
+			name:         "Down",
+			current:      3,
+			op:           OperationData{ID: operationDown},
+			wantCalled:   []string{"down"},
+			wantVersion:  2,
+			wantSequence: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mongoDB := newMongoDatabase(t)
+
+			var called []string
+			record := func(name string) MongoMigrationFunc {
+				return func(_ context.Context, db *mongo.Database) error {
+					assert.Same(t, mongoDB, db)
+					called = append(called, name)
+					return nil
+				}
+			}
+
+			runner, db := newStubRunner(t,
+				WithMongoMigration(3, "create_indexes", record("up"), record("down")),
+				WithMongoDatabase(mongoDB),
+			)
+			require.NoError(t, db.SetVersion(tt.current, false))
+
+			require.NoError(t, runner.Run(tt.op))
+			assert.Equal(t, tt.wantCalled, called)
+			assert.Equal(t, tt.wantVersion, db.CurrentVersion)
+			assert.Equal(t, tt.wantSequence, db.MigrationSequence)
+			assert.False(t, runner.ownMongoDB, "the provided database isn't closed by the runner")
+			assert.Nil(t, runner.getter, "postgres isn't connected for the mongo migrations")
+		})
+	}
+}
+
+func Test_NewRunner_MongoMigrationWithoutDatabaseName(t *testing.T) {
+	up := func(context.Context, *mongo.Database) error { return nil }
+
+	_, err := NewRunner("stub://", WithFS(stubMigrations, "."), WithMongoMigration(3, "create_indexes", up, nil))
+	assert.ErrorContains(t, err, "database name is missing in dsn")
+}
@@ -0,0 +1,142 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// FailedMigration describes the migration which failed and left the database in the dirty state.
+type FailedMigration struct {
+	Version    uint
+	Identifier string
+
+	// UpSQL is the script of the migration which failed.
+	UpSQL string
+
+	// DownSQL is the script rolling the migration back, it's empty if the migration has no down file.
+	DownSQL string
+
+	// PrevVersion is the version set by the recovery, or database.NilVersion for the first migration.
+	PrevVersion int
+}
+
+// FailedMigration returns the migration which left the database in the dirty state,
+// or nil if the database isn't dirty.
+func (m *Runner) FailedMigration() (*FailedMigration, error) {
+	version, dirty, err := m.mgr.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting current version: %w", err)
+	}
+	if !dirty {
+		return nil, nil
+	}
+
+	upSQL, identifier, err := readMigration(m.src.ReadUp, version)
+	if err != nil {
+		return nil, fmt.Errorf("reading up migration %d: %w", version, err)
+	}
+
+	downSQL, _, err := readMigration(m.src.ReadDown, version)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading down migration %d: %w", version, err)
+	}
+
+	prevVersion := database.NilVersion
+	if prev, err := m.src.Prev(version); err == nil {
+		prevVersion = int(prev) //nolint:gosec // versions fit into int
+	}
+
+	return &FailedMigration{
+		Version:     version,
+		Identifier:  identifier,
+		UpSQL:       upSQL,
+		DownSQL:     downSQL,
+		PrevVersion: prevVersion,
+	}, nil
+}
+
+// logFailedMigration reports the migration which left the database dirty, so that it can be fixed
+// and recovered with the "recover" operation or forced to the right version.
+func (m *Runner) logFailedMigration() {
+	failed, err := m.FailedMigration()
+	if err != nil {
+		m.logger.Error(fmt.Sprintf("getting failed migration: %v", err))
+		return
+	}
+	if failed == nil {
+		return
+	}
+
+	m.logger.Error(fmt.Sprintf("database is dirty after failed migration %d %s:\n%s",
+		failed.Version, failed.Identifier, failed.UpSQL))
+}
+
+// runRecover runs the "recover" migration operation: it rolls back the failed migration with its down script
+// and sets the previous version clearing the dirty flag. The down script must tolerate the partially applied
+// up migration, e.g. by using IF EXISTS, since the failed up migration could be applied partially or not at all.
+func runRecover(m *Runner, op OperationData) error {
+	failed, err := m.FailedMigration()
+	if err != nil {
+		return err
+	}
+	if failed == nil {
+		m.logger.Info("database is not dirty, nothing to recover")
+		return nil
+	}
+
+	m.logger.Info(fmt.Sprintf("running RECOVER of failed migration %d %s", failed.Version, failed.Identifier))
+
+	if failed.DownSQL == "" {
+		return fmt.Errorf("migration %d has no down script, fix the database manually and run force", failed.Version)
+	}
+
+	if op.DryRun {
+		m.logger.Info(fmt.Sprintf("dry run, down script of migration %d:\n%s", failed.Version, failed.DownSQL))
+		m.logger.Info(fmt.Sprintf("dry run, version would be set to %d", failed.PrevVersion))
+		return nil
+	}
+
+	if err = m.db.Lock(); err != nil {
+		return fmt.Errorf("locking database: %w", err)
+	}
+	defer func() {
+		if err := m.db.Unlock(); err != nil {
+			m.logger.Error(fmt.Sprintf("unlocking database: %v", err))
+		}
+	}()
+
+	if err = m.db.Run(strings.NewReader(failed.DownSQL)); err != nil {
+		return fmt.Errorf("running down script of migration %d: %w", failed.Version, err)
+	}
+
+	if err = m.db.SetVersion(failed.PrevVersion, false); err != nil {
+		return fmt.Errorf("setting version %d: %w", failed.PrevVersion, err)
+	}
+
+	m.logger.Info(fmt.Sprintf("recovered failed migration %d, version set to %d", failed.Version, failed.PrevVersion))
+	return nil
+}
+
+func readMigration(read func(version uint) (io.ReadCloser, string, error), version uint) (string, string, error) {
+	r, identifier, err := read(version)
+	if err != nil {
+		return "", "", err
+	}
+	defer r.Close()
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(body), identifier, nil
+}
@@ -0,0 +1,176 @@
+package migration
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const upPayments = "CREATE TABLE payments (id bigint);"
+
+// withoutDown adds the migration 3 which has no down file.
+var withoutDown = WithFS(fstest.MapFS{
+	"3_create_payments.up.sql": {Data: []byte(upPayments)},
+}, ".")
+
+// recordingLogger records the logged errors.
+type recordingLogger struct {
+	noopLogger
+	errors []string
+}
+
+func (l *recordingLogger) Error(args ...any) {
+	l.errors = append(l.errors, fmt.Sprint(args...))
+}
+
+func Test_Runner_FailedMigration(t *testing.T) {
+	tests := []struct {
+		name    string
+		current int
+		dirty   bool
+		want    *FailedMigration
+	}{
+		{
+			name:    "No version",
+			current: database.NilVersion,
+		},
+		{
+			name:    "Not dirty",
+			current: 2,
+		},
+		{
+			name:    "Dirty",
+			current: 2,
+			dirty:   true,
+			want: &FailedMigration{
+				Version:     2,
+				Identifier:  "create_orders",
+				UpSQL:       upOrders,
+				DownSQL:     downOrders,
+				PrevVersion: 1,
+			},
+		},
+		{
+			name:    "Dirty first migration",
+			current: 1,
+			dirty:   true,
+			want: &FailedMigration{
+				Version:     1,
+				Identifier:  "create_users",
+				UpSQL:       upUsers,
+				DownSQL:     downUsers,
+				PrevVersion: database.NilVersion,
+			},
+		},
+		{
+			name:    "Dirty without down file",
+			current: 3,
+			dirty:   true,
+			want: &FailedMigration{
+				Version:     3,
+				Identifier:  "create_payments",
+				UpSQL:       upPayments,
+				PrevVersion: 2,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, db := newStubRunner(t, withoutDown)
+			require.NoError(t, db.SetVersion(tt.current, tt.dirty))
+
+			failed, err := runner.FailedMigration()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, failed)
+		})
+	}
+}
+
+func Test_Runner_Run_Recover(t *testing.T) {
+	tests := []struct {
+		name         string
+		current      int
+		dirty        bool
+		dryRun       bool
+		wantErrText  string
+		wantVersion  int
+		wantDirty    bool
+		wantSequence []string
+	}{
+		{
+			name:         "Not dirty",
+			current:      2,
+			wantVersion:  2,
+			wantSequence: []string{},
+		},
+		{
+			name:         "Recover",
+			current:      2,
+			dirty:        true,
+			wantVersion:  1,
+			wantSequence: []string{downOrders},
+		},
+		{
+			name:         "Recover first migration",
+			current:      1,
+			dirty:        true,
+			wantVersion:  database.NilVersion,
+			wantSequence: []string{downUsers},
+		},
+		{
+			name:         "Dry run",
+			current:      2,
+			dirty:        true,
+			dryRun:       true,
+			wantVersion:  2,
+			wantDirty:    true,
+			wantSequence: []string{},
+		},
+		{
+			name:         "Without down file",
+			current:      3,
+			dirty:        true,
+			wantErrText:  "migration 3 has no down script",
+			wantVersion:  3,
+			wantDirty:    true,
+			wantSequence: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, db := newStubRunner(t, withoutDown)
+			require.NoError(t, db.SetVersion(tt.current, tt.dirty))
+
+			err := runner.Run(OperationData{ID: operationRecover, DryRun: tt.dryRun})
+			if tt.wantErrText == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErrText)
+			}
+			assert.Equal(t, tt.wantVersion, db.CurrentVersion)
+			assert.Equal(t, tt.wantDirty, db.IsDirty)
+			assert.Equal(t, tt.wantSequence, db.MigrationSequence)
+		})
+	}
+}
+
+func Test_Runner_Run_ReportsFailedMigration(t *testing.T) {
+	logger := &recordingLogger{}
+	runner, db := newStubRunner(t, WithLogger(logger))
+	require.NoError(t, db.SetVersion(2, true))
+
+	err := runner.Run(OperationData{ID: operationUp})
+	var errDirty migrate.ErrDirty
+	require.ErrorAs(t, err, &errDirty)
+
+	require.Len(t, logger.errors, 1)
+	assert.Contains(t, logger.errors[0], "failed migration 2 create_orders")
+	assert.Contains(t, logger.errors[0], upOrders)
+}
@@ -1,72 +1,167 @@
 package migration
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
 )
 
-// Constants representing environment variable keys for migration configuration.
+// Environment variable keys of the migration configuration,
+// read by RunMigrationsFromEnv and used as the flag defaults by the migrate command.
 const (
-	envKeyDsn          = "MIGRATION_DSN"
-	envKeyOp           = "MIGRATION_OPERATION"
-	envKeyForceVersion = "MIGRATION_FORCE_VERSION"
-	envKeyFilesDir     = "MIGRATION_FILES_DIR"
+	EnvKeyDsn          = "MIGRATION_DSN"
+	EnvKeyOp           = "MIGRATION_OPERATION"
+	EnvKeyForceVersion = "MIGRATION_FORCE_VERSION"
+	EnvKeyFilesDir     = "MIGRATION_FILES_DIR"
+	EnvKeyVersion      = "MIGRATION_VERSION"
+	EnvKeySteps        = "MIGRATION_STEPS"
+	EnvKeyAllowDrop    = "MIGRATION_ALLOW_DROP"
+	EnvKeyLockName     = "MIGRATION_LOCK_NAME"
+	EnvKeyLockTimeout  = "MIGRATION_LOCK_TIMEOUT"
+	EnvKeyWaitTimeout  = "MIGRATION_WAIT_TIMEOUT"
+	EnvKeyDryRun       = "MIGRATION_DRY_RUN"
+	EnvKeyNaming       = "MIGRATION_NAMING"
 )
 
-func readForceVersion() (int, error) {
-	forceVersionRaw, ok := os.LookupEnv(envKeyForceVersion)
+// EnvReader reads the values of the environment variables, collecting the errors of the invalid ones.
+// The unset variables are read as the zero values.
+type EnvReader struct {
+	errs []error
+}
+
+// Int reads the integer value of the variable.
+func (e *EnvReader) Int(key string) int {
+	raw, ok := os.LookupEnv(key)
+	if !ok {
+		return 0
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("invalid %s: %w", key, err))
+	}
+	return value
+}
+
+// Uint reads the unsigned integer value of the variable.
+func (e *EnvReader) Uint(key string) uint {
+	raw, ok := os.LookupEnv(key)
+	if !ok {
+		return 0
+	}
+
+	value, err := strconv.ParseUint(raw, 10, 0)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("invalid %s: %w", key, err))
+	}
+	return uint(value)
+}
+
+// Bool reads the boolean value of the variable, e.g. "true" or "1".
+func (e *EnvReader) Bool(key string) bool {
+	raw, ok := os.LookupEnv(key)
+	if !ok {
+		return false
+	}
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("invalid %s: %w", key, err))
+	}
+	return value
+}
+
+// Duration reads the duration value of the variable, e.g. "30s".
+func (e *EnvReader) Duration(key string) time.Duration {
+	raw, ok := os.LookupEnv(key)
 	if !ok {
-		return 0, nil
+		return 0
 	}
 
-	forceVersion, err := strconv.Atoi(forceVersionRaw)
+	value, err := time.ParseDuration(raw)
 	if err != nil {
-		return 0, fmt.Errorf("convert forceVersion: %w", err)
+		e.errs = append(e.errs, fmt.Errorf("invalid %s: %w", key, err))
 	}
+	return value
+}
 
-	return forceVersion, nil
+// Err returns the errors of all the invalid values read so far, or nil if there are none.
+func (e *EnvReader) Err() error {
+	return errors.Join(e.errs...)
 }
 
 // RunMigrationsFromEnv reads migration configuration from environment variables,
 // creates a MigrationRunner, and runs the specified migration operation.
-func RunMigrationsFromEnv(logger logger) error {
-	dsn, ok := os.LookupEnv(envKeyDsn)
+// The options are applied after the ones read from environment variables, e.g. WithFS for embedded migrations.
+func RunMigrationsFromEnv(logger logger, opts ...Option) error {
+	dsn, ok := os.LookupEnv(EnvKeyDsn)
 	if !ok {
-		return fmt.Errorf("missing env: %s", envKeyDsn)
+		return fmt.Errorf("missing env: %s", EnvKeyDsn)
 	}
 
-	operation, ok := os.LookupEnv(envKeyOp)
+	operation, ok := os.LookupEnv(EnvKeyOp)
 	if !ok {
-		return fmt.Errorf("missing env: %s", envKeyOp)
+		return fmt.Errorf("missing env: %s", EnvKeyOp)
 	}
 
-	forceVersion, err := readForceVersion()
-	if err != nil {
-		return fmt.Errorf("read forceVersion: %w", err)
+	var env EnvReader
+	forceVersion := env.Int(EnvKeyForceVersion)
+	version := env.Uint(EnvKeyVersion)
+	steps := env.Int(EnvKeySteps)
+	allowDrop := env.Bool(EnvKeyAllowDrop)
+	lockTimeout := env.Duration(EnvKeyLockTimeout)
+	waitTimeout := env.Duration(EnvKeyWaitTimeout)
+	dryRun := env.Bool(EnvKeyDryRun)
+	if err := env.Err(); err != nil {
+		return err
+	}
+	if steps < 0 {
+		return fmt.Errorf("%s must not be negative: %d", EnvKeySteps, steps)
 	}
 
-	opts := []Option{WithLogger(logger)}
-	if filesDir, exists := os.LookupEnv(envKeyFilesDir); exists {
-		opts = append(opts, WithFilesDir(filesDir))
+	envOpts := []Option{WithLogger(logger)}
+	if filesDir, exists := os.LookupEnv(EnvKeyFilesDir); exists {
+		envOpts = append(envOpts, WithFilesDir(filesDir))
+	}
+	// the lock is taken if its timeout is set
+	if lockTimeout > 0 {
+		envOpts = append(envOpts, WithLock(os.Getenv(EnvKeyLockName), lockTimeout))
 	}
 
-	runner, err := NewRunner(dsn, opts...)
+	runner, err := NewRunner(dsn, append(envOpts, opts...)...)
 	if err != nil {
 		return fmt.Errorf("new migrations runner: %w", err)
 	}
+	defer func() {
+		if err := runner.Close(); err != nil {
+			logger.Error(fmt.Sprintf("closing migrations runner: %v", err))
+		}
+	}()
 
 	// Get the current migration version and log it.
-	version, dirty, err := runner.Version()
+	currentVersion, dirty, err := runner.Version()
 	if err != nil {
 		logger.Error(fmt.Sprintf("getting current migration version: %v", err))
 	} else {
-		logger.Info(fmt.Sprintf("migration version before operation: %d, dirty: %v", version, dirty))
+		logger.Info(fmt.Sprintf("migration version before operation: %d, dirty: %v", currentVersion, dirty))
+	}
+
+	if dirty {
+		runner.logFailedMigration()
 	}
 
 	if err = runner.Run(OperationData{
 		ID:           operation,
 		ForceVersion: forceVersion,
+		Version:      version,
+		Steps:        steps,
+		AllowDrop:    allowDrop,
+		Timeout:      waitTimeout,
+		DryRun:       dryRun,
 	}); err != nil {
 		return fmt.Errorf("run operation %s: %w", operation, err)
 	}
@@ -74,12 +169,16 @@ func RunMigrationsFromEnv(logger logger) error {
 	logger.Info("successfully finished migration")
 
 	// Get the migration version after the operation and log it.
-	version, dirty, err = runner.Version()
+	currentVersion, dirty, err = runner.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		logger.Info("no migrations applied after operation")
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("getting migration version after operation: %w", err)
 	}
 
-	logger.Info(fmt.Sprintf("migration version after operation: %d, dirty: %v", version, dirty))
+	logger.Info(fmt.Sprintf("migration version after operation: %d, dirty: %v", currentVersion, dirty))
 
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,44 @@
+package migration_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/migration"
+)
+
+func Test_EnvReader(t *testing.T) {
+	t.Setenv(migration.EnvKeySteps, "2")
+	t.Setenv(migration.EnvKeyVersion, "20250102150405")
+	t.Setenv(migration.EnvKeyAllowDrop, "true")
+	t.Setenv(migration.EnvKeyLockTimeout, "30s")
+
+	var env migration.EnvReader
+	assert.Equal(t, 2, env.Int(migration.EnvKeySteps))
+	assert.Equal(t, uint(20250102150405), env.Uint(migration.EnvKeyVersion))
+	assert.True(t, env.Bool(migration.EnvKeyAllowDrop))
+	assert.Equal(t, 30*time.Second, env.Duration(migration.EnvKeyLockTimeout))
+	assert.Zero(t, env.Duration(migration.EnvKeyWaitTimeout), "unset variable")
+	assert.NoError(t, env.Err())
+}
+
+func Test_EnvReader_Invalid(t *testing.T) {
+	t.Setenv(migration.EnvKeySteps, "two")
+	t.Setenv(migration.EnvKeyDryRun, "yes please")
+
+	var env migration.EnvReader
+	env.Int(migration.EnvKeySteps)
+	env.Bool(migration.EnvKeyDryRun)
+
+	err := env.Err()
+	assert.ErrorContains(t, err, "invalid MIGRATION_STEPS")
+	assert.ErrorContains(t, err, "invalid MIGRATION_DRY_RUN")
+}
+
+func Test_MigrationStatus_State(t *testing.T) {
+	assert.Equal(t, "pending", migration.MigrationStatus{}.State())
+	assert.Equal(t, "applied", migration.MigrationStatus{Applied: true}.State())
+	assert.Equal(t, "dirty", migration.MigrationStatus{Applied: true, Dirty: true}.State())
+}
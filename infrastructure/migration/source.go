@@ -0,0 +1,202 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+const sourceName = "go-lib"
+
+// fsLocation is a directory with migration files in a file system.
+type fsLocation struct {
+	fsys fs.FS
+	dir  string
+
+	// name identifies the location in errors.
+	name string
+}
+
+type migrationKey struct {
+	version   uint
+	direction source.Direction
+}
+
+// multiSource is a golang-migrate source driver merging the migrations of several sources ordered by version:
+// the migration files read by the iofs drivers from the directory on the disk and the file systems
+// embedded into the binary with //go:embed, and the Go migrations. A single directory could be read
+// by the iofs driver alone, but the Go migrations share the versions with the files, and the migrations
+// of a service can be split between several embedded file systems, e.g. of its modules,
+// so the versions must be unique across all the sources.
+type multiSource struct {
+	migrations *source.Migrations
+	drivers    map[migrationKey]source.Driver
+	goFuncs    map[migrationKey]migrationFunc
+
+	// fileDrivers are the iofs drivers of the locations, closed with the source.
+	fileDrivers []source.Driver
+}
+
+func newMultiSource(locations []fsLocation) (*multiSource, error) {
+	src := &multiSource{
+		migrations: source.NewMigrations(),
+		drivers:    make(map[migrationKey]source.Driver),
+		goFuncs:    make(map[migrationKey]migrationFunc),
+	}
+
+	for _, location := range locations {
+		driver, err := iofs.New(location.fsys, location.dir)
+		if err != nil {
+			_ = src.Close()
+			return nil, fmt.Errorf("read migrations dir %s: %w", location.name, err)
+		}
+		src.fileDrivers = append(src.fileDrivers, driver)
+
+		if err = src.addDriver(driver, location.name); err != nil {
+			_ = src.Close()
+			return nil, err
+		}
+	}
+
+	return src, nil
+}
+
+// addDriver adds the migrations of the driver to the source.
+func (s *multiSource) addDriver(driver source.Driver, name string) error {
+	version, err := driver.First()
+	for err == nil {
+		for _, direction := range []source.Direction{source.Up, source.Down} {
+			identifier, found, readErr := identifierOf(driver, version, direction)
+			if readErr != nil {
+				return fmt.Errorf("read migration %d %s in %s: %w", version, direction, name, readErr)
+			}
+			if !found {
+				continue
+			}
+
+			m := &source.Migration{Version: version, Identifier: identifier, Direction: direction, Raw: name}
+			if !s.migrations.Append(m) {
+				return fmt.Errorf("duplicate migration %d %s in %s", version, direction, name)
+			}
+
+			s.drivers[migrationKey{version: version, direction: direction}] = driver
+		}
+
+		version, err = driver.Next(version)
+	}
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("list migrations in %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// identifierOf returns the identifier of the migration in the direction, if the driver has it.
+func identifierOf(driver source.Driver, version uint, direction source.Direction) (string, bool, error) {
+	read := driver.ReadUp
+	if direction == source.Down {
+		read = driver.ReadDown
+	}
+
+	r, identifier, err := read(version)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return identifier, true, r.Close()
+}
+
+func (s *multiSource) Open(string) (source.Driver, error) {
+	return nil, errors.New("opening by url is not supported, the source must be used as an instance")
+}
+
+func (s *multiSource) Close() error {
+	var errs []error
+	for _, driver := range s.fileDrivers {
+		errs = append(errs, driver.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (s *multiSource) First() (uint, error) {
+	version, ok := s.migrations.First()
+	if !ok {
+		return 0, &fs.PathError{Op: "first", Path: sourceName, Err: fs.ErrNotExist}
+	}
+	return version, nil
+}
+
+func (s *multiSource) Prev(version uint) (uint, error) {
+	prev, ok := s.migrations.Prev(version)
+	if !ok {
+		return 0, &fs.PathError{Op: fmt.Sprintf("prev for version %d", version), Path: sourceName, Err: fs.ErrNotExist}
+	}
+	return prev, nil
+}
+
+func (s *multiSource) Next(version uint) (uint, error) {
+	next, ok := s.migrations.Next(version)
+	if !ok {
+		return 0, &fs.PathError{Op: fmt.Sprintf("next for version %d", version), Path: sourceName, Err: fs.ErrNotExist}
+	}
+	return next, nil
+}
+
+func (s *multiSource) ReadUp(version uint) (io.ReadCloser, string, error) {
+	if m, ok := s.migrations.Up(version); ok {
+		return s.read(m)
+	}
+	return nil, "", &fs.PathError{Op: fmt.Sprintf("read up for version %d", version), Path: sourceName, Err: fs.ErrNotExist}
+}
+
+func (s *multiSource) ReadDown(version uint) (io.ReadCloser, string, error) {
+	if m, ok := s.migrations.Down(version); ok {
+		return s.read(m)
+	}
+	return nil, "", &fs.PathError{
+		Op: fmt.Sprintf("read down for version %d", version), Path: sourceName, Err: fs.ErrNotExist,
+	}
+}
+
+func (s *multiSource) read(m *source.Migration) (io.ReadCloser, string, error) {
+	key := migrationKey{version: m.Version, direction: m.Direction}
+	if _, ok := s.goFuncs[key]; ok {
+		marker := fmt.Sprintf("%s %d %s", goMigrationMarker, m.Version, m.Direction)
+		return io.NopCloser(strings.NewReader(marker)), m.Identifier, nil
+	}
+
+	if m.Direction == source.Down {
+		return s.drivers[key].ReadDown(m.Version)
+	}
+	return s.drivers[key].ReadUp(m.Version)
+}
+
+// list returns the up migrations ordered by version.
+func (s *multiSource) list() []*source.Migration {
+	var migrations []*source.Migration
+
+	version, ok := s.migrations.First()
+	for ok {
+		if m, found := s.migrations.Up(version); found {
+			migrations = append(migrations, m)
+		}
+		version, ok = s.migrations.Next(version)
+	}
+
+	return migrations
+}
+
+// dirLocation returns the location of the migration files in the directory on the disk.
+func dirLocation(dir string) fsLocation {
+	return fsLocation{fsys: os.DirFS(dir), dir: ".", name: dir}
+}
@@ -0,0 +1,99 @@
+package migration
+
+import (
+	"context"
+	"io"
+	"testing"
+	"testing/fstest"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, read func(uint) (io.ReadCloser, string, error), version uint) (string, string) {
+	t.Helper()
+
+	r, identifier, err := read(version)
+	require.NoError(t, err)
+	defer r.Close()
+
+	body, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	return string(body), identifier
+}
+
+func Test_multiSource(t *testing.T) {
+	users := fstest.MapFS{
+		"migrations/000001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id BIGINT);")},
+		"migrations/000001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"migrations/000003_add_name.up.sql":       {Data: []byte("ALTER TABLE users ADD COLUMN name TEXT;")},
+		"migrations/README.md":                    {Data: []byte("not a migration")},
+	}
+	orders := fstest.MapFS{
+		"000002_create_orders.up.sql": {Data: []byte("CREATE TABLE orders (id BIGINT);")},
+	}
+
+	src, err := newMultiSource([]fsLocation{
+		{fsys: users, dir: "migrations", name: "users"},
+		{fsys: orders, dir: ".", name: "orders"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, src.addGoMigrations([]goMigration{{
+		version: 4,
+		name:    "backfill_names",
+		up:      func(context.Context, *goDriver) error { return nil },
+	}}))
+	defer src.Close()
+
+	var versions []uint
+	for _, m := range src.list() {
+		versions = append(versions, m.Version)
+	}
+	assert.Equal(t, []uint{1, 2, 3, 4}, versions)
+
+	body, identifier := readAll(t, src.ReadUp, 2)
+	assert.Equal(t, "CREATE TABLE orders (id BIGINT);", body)
+	assert.Equal(t, "create_orders", identifier)
+
+	body, _ = readAll(t, src.ReadDown, 1)
+	assert.Equal(t, "DROP TABLE users;", body)
+
+	body, identifier = readAll(t, src.ReadUp, 4)
+	assert.Equal(t, goMigrationMarker+" 4 "+string(source.Up), body)
+	assert.Equal(t, "backfill_names", identifier)
+
+	_, _, err = src.ReadDown(3)
+	assert.Error(t, err)
+
+	prev, err := src.Prev(3)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), prev)
+}
+
+func Test_multiSource_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		locations []fsLocation
+	}{
+		{
+			name: "Duplicate version",
+			locations: []fsLocation{
+				{fsys: fstest.MapFS{"000001_create_users.up.sql": {}}, dir: ".", name: "users"},
+				{fsys: fstest.MapFS{"000001_create_orders.up.sql": {}}, dir: ".", name: "orders"},
+			},
+		},
+		{
+			name:      "Missing directory",
+			locations: []fsLocation{{fsys: fstest.MapFS{}, dir: "migrations", name: "migrations"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newMultiSource(tt.locations)
+			assert.Error(t, err)
+		})
+	}
+}
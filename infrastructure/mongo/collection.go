@@ -0,0 +1,350 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/kodenkai-labs/go-lib/errlib"
+)
+
+// Names of the timestamp fields maintained by Collection.
+const (
+	FieldCreatedAt = "created_at"
+	FieldUpdatedAt = "updated_at"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+)
+
+type collectionConfig struct {
+	notFoundSlug errlib.Slug
+	conflictSlug errlib.Slug
+	indexes      []Index
+}
+
+// CollectionOption represents a function that configures a Collection.
+type CollectionOption func(cfg *collectionConfig)
+
+// WithNotFoundSlug sets the slug of the errors returned when the document is not found.
+// If not provided, errlib.SlugNotFound will be used by default.
+func WithNotFoundSlug(slug errlib.Slug) CollectionOption {
+	return func(cfg *collectionConfig) {
+		cfg.notFoundSlug = slug
+	}
+}
+
+// WithConflictSlug sets the slug of the errors returned when the document violates a unique index.
+// If not provided, errlib.SlugAlreadyExists will be used by default.
+func WithConflictSlug(slug errlib.Slug) CollectionOption {
+	return func(cfg *collectionConfig) {
+		cfg.conflictSlug = slug
+	}
+}
+
+// Collection implements the common operations for the documents of the type T.
+// Operations run in the transaction carried by the context, if any, see DB.Transaction.
+// Errors are translated by TranslateError: mongo.ErrNoDocuments is returned as errlib.AppError
+// with errlib.NotFoundCode, and duplicate key errors are returned as errlib.AppError with errlib.ConflictCode.
+//
+// If T has the time.Time fields tagged `bson:"created_at"` and `bson:"updated_at"`, they are maintained
+// automatically: both are set by Insert and Upsert of a new document, and updated_at is set by Update and Upsert.
+// If T has the primitive.ObjectID field tagged `bson:"_id"`, Insert generates it when it's zero.
+type Collection[T any] struct {
+	coll *mongo.Collection
+	cfg  collectionConfig
+
+	// indexes of the fields in T, nil if T has no such field
+	idField        []int
+	createdAtField []int
+	updatedAtField []int
+}
+
+// NewCollection creates a new Collection for the documents of the type T stored in the collection of the database.
+func NewCollection[T any](db *mongo.Database, name string, opts ...CollectionOption) *Collection[T] {
+	cfg := collectionConfig{
+		notFoundSlug: errlib.SlugNotFound,
+		conflictSlug: errlib.SlugAlreadyExists,
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	c := &Collection[T]{
+		coll: db.Collection(name),
+		cfg:  cfg,
+	}
+
+	if typ := reflect.TypeOf((*T)(nil)).Elem(); typ.Kind() == reflect.Struct {
+		c.idField = findField(typ, "_id", objectIDType)
+		c.createdAtField = findField(typ, FieldCreatedAt, timeType)
+		c.updatedAtField = findField(typ, FieldUpdatedAt, timeType)
+	}
+
+	return c
+}
+
+// Collection returns the collection of the documents to build custom operations.
+func (c *Collection[T]) Collection() *mongo.Collection {
+	return c.coll
+}
+
+// FindOne returns the first document matching the filter.
+func (c *Collection[T]) FindOne(ctx context.Context, filter any, opts ...*options.FindOneOptions) (*T, error) {
+	var document T
+	if err := c.coll.FindOne(ctx, filter, opts...).Decode(&document); err != nil {
+		return nil, c.wrapError(fmt.Errorf("find document: %w", err))
+	}
+
+	return &document, nil
+}
+
+// Find returns the documents matching the filter.
+func (c *Collection[T]) Find(ctx context.Context, filter any, opts ...*options.FindOptions) ([]T, error) {
+	cursor, err := c.coll.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, c.wrapError(fmt.Errorf("find documents: %w", err))
+	}
+
+	var documents []T
+	if err = cursor.All(ctx, &documents); err != nil {
+		return nil, c.wrapError(fmt.Errorf("decode documents: %w", err))
+	}
+
+	return documents, nil
+}
+
+// Insert inserts the document, setting its timestamps and generating its zero ObjectID.
+func (c *Collection[T]) Insert(ctx context.Context, document *T) error {
+	c.setInsertFields(document, c.now())
+
+	if _, err := c.coll.InsertOne(ctx, document); err != nil {
+		return c.wrapError(fmt.Errorf("insert document: %w", err))
+	}
+
+	return nil
+}
+
+// Update updates the first document matching the filter with the update document, e.g. bson.M{"$set": ...},
+// setting its updated_at timestamp. The update must consist of update operators: aggregation pipeline updates
+// aren't supported, since the timestamps can't be added to them, use Collection for them instead.
+func (c *Collection[T]) Update(ctx context.Context, filter, update any) error {
+	var set bson.D
+	if c.updatedAtField != nil {
+		set = bson.D{{Key: FieldUpdatedAt, Value: c.now()}}
+	}
+
+	update, err := addOperatorFields(update, set, nil)
+	if err != nil {
+		return fmt.Errorf("update document: %w", err)
+	}
+
+	result, err := c.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return c.wrapError(fmt.Errorf("update document: %w", err))
+	}
+	if result.MatchedCount == 0 {
+		return c.wrapError(fmt.Errorf("update document: %w", mongo.ErrNoDocuments))
+	}
+
+	return nil
+}
+
+// Upsert updates the first document matching the filter with the update document or inserts a new one,
+// setting its timestamps. Like Update, it doesn't support aggregation pipeline updates.
+func (c *Collection[T]) Upsert(ctx context.Context, filter, update any) error {
+	now := c.now()
+
+	var set, setOnInsert bson.D
+	if c.updatedAtField != nil {
+		set = bson.D{{Key: FieldUpdatedAt, Value: now}}
+	}
+	if c.createdAtField != nil {
+		setOnInsert = bson.D{{Key: FieldCreatedAt, Value: now}}
+	}
+
+	update, err := addOperatorFields(update, set, setOnInsert)
+	if err != nil {
+		return fmt.Errorf("upsert document: %w", err)
+	}
+
+	if _, err = c.coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		return c.wrapError(fmt.Errorf("upsert document: %w", err))
+	}
+
+	return nil
+}
+
+// Delete deletes the first document matching the filter.
+func (c *Collection[T]) Delete(ctx context.Context, filter any) error {
+	result, err := c.coll.DeleteOne(ctx, filter)
+	if err != nil {
+		return c.wrapError(fmt.Errorf("delete document: %w", err))
+	}
+	if result.DeletedCount == 0 {
+		return c.wrapError(fmt.Errorf("delete document: %w", mongo.ErrNoDocuments))
+	}
+
+	return nil
+}
+
+// Aggregate runs the aggregation pipeline and returns its results decoded as T.
+// Use AggregateAs for the results of a different shape.
+func (c *Collection[T]) Aggregate(ctx context.Context, pipeline any, opts ...*options.AggregateOptions) ([]T, error) {
+	return AggregateAs[T](ctx, c, pipeline, opts...)
+}
+
+// AggregateAs runs the aggregation pipeline on the collection and returns its results decoded as R.
+func AggregateAs[R, T any](
+	ctx context.Context, c *Collection[T], pipeline any, opts ...*options.AggregateOptions,
+) ([]R, error) {
+	cursor, err := c.coll.Aggregate(ctx, pipeline, opts...)
+	if err != nil {
+		return nil, c.wrapError(fmt.Errorf("aggregate documents: %w", err))
+	}
+
+	var results []R
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, c.wrapError(fmt.Errorf("decode aggregation results: %w", err))
+	}
+
+	return results, nil
+}
+
+// setInsertFields generates the zero ObjectID of the new document and sets its timestamps,
+// keeping created_at if it's set explicitly.
+func (c *Collection[T]) setInsertFields(document *T, now time.Time) {
+	value := reflect.ValueOf(document).Elem()
+
+	if field := fieldByIndex(value, c.idField); field.IsValid() && field.IsZero() {
+		field.Set(reflect.ValueOf(primitive.NewObjectID()))
+	}
+	if field := fieldByIndex(value, c.createdAtField); field.IsValid() && field.IsZero() {
+		field.Set(reflect.ValueOf(now))
+	}
+	if field := fieldByIndex(value, c.updatedAtField); field.IsValid() {
+		field.Set(reflect.ValueOf(now))
+	}
+}
+
+// now returns the current time with the millisecond precision of the BSON dates,
+// so that the set timestamps are equal to the stored ones.
+func (c *Collection[T]) now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// wrapError translates the error by TranslateError, replacing the not found and conflict slugs
+// with the ones configured for the collection.
+func (c *Collection[T]) wrapError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return errlib.NewAppError(err, errlib.NotFoundCode, c.cfg.notFoundSlug)
+	}
+
+	if mongo.IsDuplicateKeyError(err) {
+		return errlib.NewAppError(err, errlib.ConflictCode, c.cfg.conflictSlug)
+	}
+
+	return TranslateError(err)
+}
+
+// addOperatorFields adds the fields to the $set and $setOnInsert operators of the update document,
+// unless the update sets them explicitly.
+func addOperatorFields(update any, set, setOnInsert bson.D) (bson.D, error) {
+	if kind := reflect.ValueOf(update).Kind(); kind == reflect.Slice || kind == reflect.Array {
+		switch update.(type) {
+		case bson.D, bson.Raw:
+		default:
+			return nil, errors.New("pipeline updates aren't supported, use Collection() to run them")
+		}
+	}
+
+	raw, err := bson.Marshal(update)
+	if err != nil {
+		return nil, fmt.Errorf("marshal update: %w", err)
+	}
+
+	var document bson.D
+	if err = bson.Unmarshal(raw, &document); err != nil {
+		return nil, fmt.Errorf("unmarshal update: %w", err)
+	}
+
+	explicit := make(map[string]bool)
+	for _, operator := range document {
+		if !strings.HasPrefix(operator.Key, "$") {
+			return nil, fmt.Errorf("update must contain only operators, got %s", operator.Key)
+		}
+
+		fields, _ := operator.Value.(bson.D)
+		for _, field := range fields {
+			explicit[field.Key] = true
+		}
+	}
+
+	document = mergeOperator(document, "$set", set, explicit)
+	document = mergeOperator(document, "$setOnInsert", setOnInsert, explicit)
+
+	return document, nil
+}
+
+func mergeOperator(document bson.D, operator string, fields bson.D, explicit map[string]bool) bson.D {
+	var missing bson.D
+	for _, field := range fields {
+		if !explicit[field.Key] {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) == 0 {
+		return document
+	}
+
+	for i, element := range document {
+		if element.Key == operator {
+			existing, _ := element.Value.(bson.D)
+			document[i].Value = append(existing, missing...)
+			return document
+		}
+	}
+
+	return append(document, bson.E{Key: operator, Value: missing})
+}
+
+// findField returns the index of the field of the type with the given bson name, or nil if there is none.
+func findField(typ reflect.Type, name string, fieldType reflect.Type) []int {
+	for _, field := range reflect.VisibleFields(typ) {
+		if !field.IsExported() || field.Type != fieldType {
+			continue
+		}
+
+		tagName, _, _ := strings.Cut(field.Tag.Get("bson"), ",")
+		if tagName == name {
+			return field.Index
+		}
+	}
+
+	return nil
+}
+
+// fieldByIndex returns the field of the struct value, or the zero Value if the index is nil.
+func fieldByIndex(value reflect.Value, index []int) reflect.Value {
+	if index == nil {
+		return reflect.Value{}
+	}
+
+	field, err := value.FieldByIndexErr(index)
+	if err != nil {
+		// the embedded struct pointer is nil
+		return reflect.Value{}
+	}
+	return field
+}
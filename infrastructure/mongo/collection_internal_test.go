@@ -0,0 +1,249 @@
+package mongo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Timestamps struct {
+	CreatedAt time.Time `bson:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at,omitempty"`
+}
+
+type embeddedDocument struct {
+	ID   primitive.ObjectID `bson:"_id"`
+	Name string             `bson:"name"`
+
+	Timestamps `bson:",inline"`
+}
+
+type embeddedPointerDocument struct {
+	ID primitive.ObjectID `bson:"_id,omitempty"`
+
+	*Timestamps `bson:",inline"`
+}
+
+type untypedDocument struct {
+	ID        string `bson:"_id"`
+	CreatedAt string `bson:"created_at"`
+	updatedAt time.Time
+}
+
+// testDatabase returns a database of a client which isn't connected to any server,
+// since the collections are created lazily.
+func testDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = client.Disconnect(context.Background())
+	})
+
+	return client.Database("test")
+}
+
+func Test_findField(t *testing.T) {
+	tests := []struct {
+		name      string
+		typ       reflect.Type
+		field     string
+		fieldType reflect.Type
+		want      []int
+	}{
+		{
+			name:      "Direct field",
+			typ:       reflect.TypeOf(embeddedDocument{}),
+			field:     "_id",
+			fieldType: objectIDType,
+			want:      []int{0},
+		},
+		{
+			name:      "Embedded field",
+			typ:       reflect.TypeOf(embeddedDocument{}),
+			field:     FieldCreatedAt,
+			fieldType: timeType,
+			want:      []int{2, 0},
+		},
+		{
+			name:      "Tag with options",
+			typ:       reflect.TypeOf(embeddedDocument{}),
+			field:     FieldUpdatedAt,
+			fieldType: timeType,
+			want:      []int{2, 1},
+		},
+		{
+			name:      "Embedded pointer field",
+			typ:       reflect.TypeOf(embeddedPointerDocument{}),
+			field:     FieldUpdatedAt,
+			fieldType: timeType,
+			want:      []int{1, 1},
+		},
+		{
+			name:      "Different type",
+			typ:       reflect.TypeOf(untypedDocument{}),
+			field:     FieldCreatedAt,
+			fieldType: timeType,
+		},
+		{
+			name:      "Unexported field",
+			typ:       reflect.TypeOf(untypedDocument{}),
+			field:     "updatedAt",
+			fieldType: timeType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, findField(tt.typ, tt.field, tt.fieldType))
+		})
+	}
+}
+
+func Test_Collection_setInsertFields(t *testing.T) {
+	db := testDatabase(t)
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	t.Run("New document", func(t *testing.T) {
+		document := &embeddedDocument{Name: "name"}
+		NewCollection[embeddedDocument](db, "documents").setInsertFields(document, now)
+
+		assert.False(t, document.ID.IsZero())
+		assert.Equal(t, now, document.CreatedAt)
+		assert.Equal(t, now, document.UpdatedAt)
+	})
+
+	t.Run("Explicit fields", func(t *testing.T) {
+		id := primitive.NewObjectID()
+		createdAt := now.Add(-time.Hour)
+		document := &embeddedDocument{ID: id, Timestamps: Timestamps{CreatedAt: createdAt, UpdatedAt: createdAt}}
+		NewCollection[embeddedDocument](db, "documents").setInsertFields(document, now)
+
+		assert.Equal(t, id, document.ID)
+		assert.Equal(t, createdAt, document.CreatedAt)
+		assert.Equal(t, now, document.UpdatedAt)
+	})
+
+	t.Run("Nil embedded pointer", func(t *testing.T) {
+		document := &embeddedPointerDocument{}
+		NewCollection[embeddedPointerDocument](db, "documents").setInsertFields(document, now)
+
+		assert.False(t, document.ID.IsZero())
+		assert.Nil(t, document.Timestamps)
+	})
+
+	t.Run("No typed fields", func(t *testing.T) {
+		document := &untypedDocument{}
+		NewCollection[untypedDocument](db, "documents").setInsertFields(document, now)
+
+		assert.Equal(t, untypedDocument{}, *document)
+	})
+}
+
+func Test_addOperatorFields(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	explicit := now.Add(-time.Hour)
+
+	set := bson.D{{Key: FieldUpdatedAt, Value: now}}
+	setOnInsert := bson.D{{Key: FieldCreatedAt, Value: now}}
+
+	tests := []struct {
+		name        string
+		update      any
+		set         bson.D
+		setOnInsert bson.D
+		want        bson.D
+		wantErr     bool
+	}{
+		{
+			name:   "Added to existing $set",
+			update: bson.M{"$set": bson.M{"name": "name"}},
+			set:    set,
+			want: bson.D{
+				{Key: "$set", Value: bson.D{{Key: "name", Value: "name"}, {Key: FieldUpdatedAt, Value: now}}},
+			},
+		},
+		{
+			name:   "New $set",
+			update: bson.D{{Key: "$inc", Value: bson.D{{Key: "count", Value: int32(1)}}}},
+			set:    set,
+			want: bson.D{
+				{Key: "$inc", Value: bson.D{{Key: "count", Value: int32(1)}}},
+				{Key: "$set", Value: bson.D{{Key: FieldUpdatedAt, Value: now}}},
+			},
+		},
+		{
+			name:   "Explicit field is kept",
+			update: bson.D{{Key: "$set", Value: bson.D{{Key: FieldUpdatedAt, Value: explicit}}}},
+			set:    set,
+			want: bson.D{
+				{Key: "$set", Value: bson.D{{Key: FieldUpdatedAt, Value: primitive.NewDateTimeFromTime(explicit)}}},
+			},
+		},
+		{
+			name:        "Upsert",
+			update:      bson.D{{Key: "$set", Value: bson.D{{Key: "name", Value: "name"}}}},
+			set:         set,
+			setOnInsert: setOnInsert,
+			want: bson.D{
+				{Key: "$set", Value: bson.D{{Key: "name", Value: "name"}, {Key: FieldUpdatedAt, Value: now}}},
+				{Key: "$setOnInsert", Value: bson.D{{Key: FieldCreatedAt, Value: now}}},
+			},
+		},
+		{
+			name:        "Explicit field in another operator",
+			update:      bson.D{{Key: "$unset", Value: bson.D{{Key: FieldCreatedAt, Value: ""}}}},
+			setOnInsert: setOnInsert,
+			want: bson.D{
+				{Key: "$unset", Value: bson.D{{Key: FieldCreatedAt, Value: ""}}},
+			},
+		},
+		{
+			name:   "No fields",
+			update: bson.D{{Key: "$set", Value: bson.D{{Key: "name", Value: "name"}}}},
+			want: bson.D{
+				{Key: "$set", Value: bson.D{{Key: "name", Value: "name"}}},
+			},
+		},
+		{
+			name:    "Replacement document",
+			update:  bson.D{{Key: "name", Value: "name"}},
+			set:     set,
+			wantErr: true,
+		},
+		{
+			name:    "Pipeline",
+			update:  mongo.Pipeline{{{Key: "$set", Value: bson.D{{Key: "name", Value: "name"}}}}},
+			set:     set,
+			wantErr: true,
+		},
+		{
+			name:    "Pipeline of maps",
+			update:  []bson.M{{"$set": bson.M{"name": "name"}}},
+			set:     set,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := addOperatorFields(tt.update, tt.set, tt.setOnInsert)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,8 +1,23 @@
 package mongo
 
-import "time"
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"go.mongodb.org/mongo-driver/tag"
+)
 
 // Config represents the configuration for a database connection.
+// The optional settings override the ones of the URI.
 type Config struct {
 	// URI is the URI of the read-write database instance to connect to.
 	URI string `mapstructure:"uri"`
@@ -13,6 +28,231 @@ type Config struct {
 	// MinPoolSize is the minimum number of connections in the connection pool.
 	MinPoolSize uint64 `mapstructure:"min_pool_size"`
 
+	// MaxPoolSize is the maximum number of connections in the connection pool.
+	// This is optional and the default value is 100.
+	MaxPoolSize uint64 `mapstructure:"max_pool_size"`
+
 	// MaxConnIdleTime is the maximum amount of time that a connection can remain idle in the connection pool.
 	MaxConnIdleTime time.Duration `mapstructure:"max_conn_idle_time"`
-}
\ No newline at end of file
+
+	// ConnectTimeout is the timeout of establishing a connection, also used for the initial ping.
+	// This is optional and the default value is 5 seconds.
+	ConnectTimeout time.Duration `mapstructure:"connect_timeout"`
+
+	// ServerSelectionTimeout is the timeout of finding a suitable server for an operation.
+	// This is optional and the default value is 30 seconds.
+	ServerSelectionTimeout time.Duration `mapstructure:"server_selection_timeout"`
+
+	// SocketTimeout is the timeout of a socket read or write.
+	// This is optional and the default value is 0, meaning no timeout.
+	SocketTimeout time.Duration `mapstructure:"socket_timeout"`
+
+	// WriteConcern is the acknowledgment requested for the writes.
+	// This is optional and the default is the one of the URI, or w:1 if it's not set there.
+	WriteConcern *WriteConcern `mapstructure:"write_concern"`
+
+	// ReadPreference is the choice of the members serving the reads of the read database.
+	// This is optional and the default is the "secondary" mode. If it's set, ReadPreference.Mode is required.
+	ReadPreference *ReadPreference `mapstructure:"read_preference"`
+
+	// ReadConcern is the consistency level of the reads.
+	// Possible values are "local", "available", "majority", "linearizable" and "snapshot".
+	// This is optional and the default is the server's default.
+	ReadConcern string `mapstructure:"read_concern"`
+
+	// Compressors is the list of the compressors of the network traffic in order of preference.
+	// Possible values are "snappy", "zlib" and "zstd". This is optional and the traffic isn't compressed by default.
+	Compressors []string `mapstructure:"compressors"`
+
+	// TLS is the TLS settings of the connection.
+	// This is optional and can be set to nil if TLS is not required or configured by the URI.
+	TLS *TLSConfig `mapstructure:"tls"`
+}
+
+// WriteConcern represents the write concern settings.
+type WriteConcern struct {
+	// W is the number of members that must acknowledge the write, or "majority".
+	W string `mapstructure:"w"`
+
+	// Journal requests the acknowledgment after the write is written to the on-disk journal.
+	Journal bool `mapstructure:"journal"`
+}
+
+// ReadPreference represents the read preference settings.
+type ReadPreference struct {
+	// Mode is one of "primary", "primaryPreferred", "secondary", "secondaryPreferred" and "nearest".
+	// It's required.
+	Mode string `mapstructure:"mode"`
+
+	// TagSets is the list of the tag sets the members must match, tried in order.
+	// It can't be used with the "primary" mode.
+	TagSets []map[string]string `mapstructure:"tag_sets"`
+
+	// MaxStaleness is the maximum replication lag of the secondaries serving the reads, at least 90 seconds.
+	// It can't be used with the "primary" mode.
+	MaxStaleness time.Duration `mapstructure:"max_staleness"`
+}
+
+// TLSConfig represents the TLS settings.
+type TLSConfig struct {
+	// CAFile is the path of the PEM file with the certificate authorities verifying the server certificate.
+	// This is optional and the system certificate pool is used by default.
+	CAFile string `mapstructure:"ca_file"`
+
+	// CertFile and KeyFile are the paths of the PEM files with the client certificate and its private key.
+	// They are optional and needed only for the client certificate authentication.
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+
+	// InsecureSkipVerify disables the verification of the server certificate, e.g. for local development.
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+}
+
+// ClientOptions returns the client options built from the configuration.
+// The read preference isn't included, since it's applied to the read database only.
+func (cfg Config) ClientOptions() (*options.ClientOptions, error) {
+	cOpts := options.Client().
+		ApplyURI(cfg.URI).
+		SetMinPoolSize(cfg.MinPoolSize).
+		SetMaxConnIdleTime(cfg.MaxConnIdleTime)
+
+	if cfg.MaxPoolSize > 0 {
+		cOpts.SetMaxPoolSize(cfg.MaxPoolSize)
+	}
+	if cfg.ConnectTimeout > 0 {
+		cOpts.SetConnectTimeout(cfg.ConnectTimeout)
+	}
+	if cfg.ServerSelectionTimeout > 0 {
+		cOpts.SetServerSelectionTimeout(cfg.ServerSelectionTimeout)
+	}
+	if cfg.SocketTimeout > 0 {
+		cOpts.SetSocketTimeout(cfg.SocketTimeout)
+	}
+	if len(cfg.Compressors) > 0 {
+		cOpts.SetCompressors(cfg.Compressors)
+	}
+
+	if cfg.WriteConcern != nil {
+		writeConcern, err := cfg.WriteConcern.build()
+		if err != nil {
+			return nil, fmt.Errorf("write concern: %w", err)
+		}
+		cOpts.SetWriteConcern(writeConcern)
+	} else if cOpts.WriteConcern == nil {
+		// https://www.mongodb.com/docs/manual/reference/write-concern/
+		cOpts.SetWriteConcern(writeconcern.W1())
+	}
+
+	if cfg.ReadConcern != "" {
+		readConcern, err := buildReadConcern(cfg.ReadConcern)
+		if err != nil {
+			return nil, fmt.Errorf("read concern: %w", err)
+		}
+		cOpts.SetReadConcern(readConcern)
+	}
+
+	if cfg.TLS != nil {
+		tlsConfig, err := cfg.TLS.build()
+		if err != nil {
+			return nil, fmt.Errorf("tls: %w", err)
+		}
+		cOpts.SetTLSConfig(tlsConfig)
+	}
+
+	if err := cOpts.Validate(); err != nil {
+		return nil, fmt.Errorf("validate client options: %w", err)
+	}
+
+	return cOpts, nil
+}
+
+// connectTimeout returns the timeout of connecting and the initial ping.
+func (cfg Config) connectTimeout() time.Duration {
+	if cfg.ConnectTimeout > 0 {
+		return cfg.ConnectTimeout
+	}
+	return timeout
+}
+
+// build returns the write concern, see https://www.mongodb.com/docs/manual/reference/write-concern/
+func (wc *WriteConcern) build() (*writeconcern.WriteConcern, error) {
+	writeConcern := &writeconcern.WriteConcern{W: 1}
+	switch wc.W {
+	case "":
+	case "majority":
+		writeConcern.W = "majority"
+	default:
+		w, err := strconv.Atoi(wc.W)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("invalid w: %s", wc.W)
+		}
+		writeConcern.W = w
+	}
+
+	if wc.Journal {
+		writeConcern.Journal = &wc.Journal
+	}
+
+	return writeConcern, nil
+}
+
+// build returns the read preference, secondary if the settings are nil.
+func (rp *ReadPreference) build() (*readpref.ReadPref, error) {
+	if rp == nil {
+		return readpref.Secondary(), nil
+	}
+
+	mode, err := readpref.ModeFromString(rp.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []readpref.Option
+	if len(rp.TagSets) > 0 {
+		opts = append(opts, readpref.WithTagSets(tag.NewTagSetsFromMaps(rp.TagSets)...))
+	}
+	if rp.MaxStaleness > 0 {
+		opts = append(opts, readpref.WithMaxStaleness(rp.MaxStaleness))
+	}
+
+	return readpref.New(mode, opts...)
+}
+
+func buildReadConcern(level string) (*readconcern.ReadConcern, error) {
+	switch level {
+	case "local", "available", "majority", "linearizable", "snapshot":
+		return &readconcern.ReadConcern{Level: level}, nil
+	default:
+		return nil, fmt.Errorf("invalid level: %s", level)
+	}
+}
+
+func (t *TLSConfig) build() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: t.InsecureSkipVerify, //nolint:gosec // explicitly configured
+	}
+
+	if t.CAFile != "" {
+		ca, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no certificates in ca file")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
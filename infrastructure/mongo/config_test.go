@@ -0,0 +1,102 @@
+package mongo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+
+	golibmongo "github.com/kodenkai-labs/go-lib/infrastructure/mongo"
+)
+
+func Test_Config_ClientOptions(t *testing.T) {
+	cfg := golibmongo.Config{
+		URI:                    "mongodb://localhost:27017/?w=2",
+		Name:                   "test",
+		MinPoolSize:            5,
+		MaxPoolSize:            50,
+		ConnectTimeout:         3 * time.Second,
+		ServerSelectionTimeout: 10 * time.Second,
+		SocketTimeout:          20 * time.Second,
+		WriteConcern:           &golibmongo.WriteConcern{W: "majority", Journal: true},
+		ReadConcern:            "majority",
+		Compressors:            []string{"zstd", "snappy"},
+		TLS:                    &golibmongo.TLSConfig{InsecureSkipVerify: true},
+	}
+
+	cOpts, err := cfg.ClientOptions()
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(5), *cOpts.MinPoolSize)
+	assert.Equal(t, uint64(50), *cOpts.MaxPoolSize)
+	assert.Equal(t, 3*time.Second, *cOpts.ConnectTimeout)
+	assert.Equal(t, 10*time.Second, *cOpts.ServerSelectionTimeout)
+	assert.Equal(t, 20*time.Second, *cOpts.SocketTimeout)
+	assert.Equal(t, "majority", cOpts.WriteConcern.W)
+	assert.True(t, *cOpts.WriteConcern.Journal)
+	assert.Equal(t, "majority", cOpts.ReadConcern.Level)
+	assert.Equal(t, []string{"zstd", "snappy"}, cOpts.Compressors)
+	require.NotNil(t, cOpts.TLSConfig)
+	assert.True(t, cOpts.TLSConfig.InsecureSkipVerify)
+}
+
+func Test_Config_ClientOptions_Defaults(t *testing.T) {
+	cOpts, err := golibmongo.Config{URI: "mongodb://localhost:27017", Name: "test"}.ClientOptions()
+	require.NoError(t, err)
+
+	assert.Equal(t, writeconcern.W1(), cOpts.WriteConcern)
+	assert.Nil(t, cOpts.MaxPoolSize)
+	assert.Nil(t, cOpts.ReadConcern)
+	assert.Nil(t, cOpts.TLSConfig)
+}
+
+func Test_Config_ClientOptions_URIWriteConcern(t *testing.T) {
+	cOpts, err := golibmongo.Config{URI: "mongodb://localhost:27017/?w=majority", Name: "test"}.ClientOptions()
+	require.NoError(t, err)
+
+	assert.Equal(t, writeconcern.Majority(), cOpts.WriteConcern)
+}
+
+func Test_Config_ClientOptions_URIJournal(t *testing.T) {
+	cOpts, err := golibmongo.Config{URI: "mongodb://localhost:27017/?journal=true", Name: "test"}.ClientOptions()
+	require.NoError(t, err)
+
+	require.NotNil(t, cOpts.WriteConcern)
+	assert.Nil(t, cOpts.WriteConcern.W, "the server's default w is kept with the journal set by the URI")
+	assert.True(t, *cOpts.WriteConcern.Journal)
+}
+
+func Test_Config_ClientOptions_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  golibmongo.Config
+	}{
+		{
+			name: "Invalid w",
+			cfg:  golibmongo.Config{WriteConcern: &golibmongo.WriteConcern{W: "all"}},
+		},
+		{
+			name: "Invalid read concern",
+			cfg:  golibmongo.Config{ReadConcern: "strong"},
+		},
+		{
+			name: "Missing ca file",
+			cfg:  golibmongo.Config{TLS: &golibmongo.TLSConfig{CAFile: "/nonexistent/ca.pem"}},
+		},
+		{
+			name: "Key file without cert file",
+			cfg:  golibmongo.Config{TLS: &golibmongo.TLSConfig{KeyFile: "/nonexistent/key.pem"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.URI = "mongodb://localhost:27017"
+
+			_, err := tt.cfg.ClientOptions()
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+
+	"github.com/kodenkai-labs/go-lib/errlib"
+)
+
+// TranslateError converts the database error to errlib.AppError with the code and slug matching its cause:
+//   - mongo.ErrNoDocuments: errlib.NotFoundCode, errlib.SlugNotFound;
+//   - duplicate key errors: errlib.ConflictCode, errlib.SlugAlreadyExists;
+//   - server selection and network errors: errlib.InternalCode, errlib.SlugDatabaseUnavailable;
+//   - timeouts and context cancellation: errlib.InternalCode, errlib.SlugRequestCanceled.
+//
+// Other errors, including nil, are returned unchanged.
+func TranslateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr errlib.AppError
+	if errors.As(err, &appErr) {
+		return err
+	}
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return errlib.NewAppError(err, errlib.NotFoundCode, errlib.SlugNotFound)
+	}
+
+	if mongo.IsDuplicateKeyError(err) {
+		return errlib.NewAppError(err, errlib.ConflictCode, errlib.SlugAlreadyExists)
+	}
+
+	var selectionErr topology.ServerSelectionError
+	if errors.As(err, &selectionErr) || mongo.IsNetworkError(err) {
+		return errlib.NewAppError(err, errlib.InternalCode, errlib.SlugDatabaseUnavailable)
+	}
+
+	if errors.Is(err, context.Canceled) || mongo.IsTimeout(err) {
+		return errlib.NewAppError(err, errlib.InternalCode, errlib.SlugRequestCanceled)
+	}
+
+	return err
+}
@@ -0,0 +1,75 @@
+package mongo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+
+	"github.com/kodenkai-labs/go-lib/errlib"
+	golibmongo "github.com/kodenkai-labs/go-lib/infrastructure/mongo"
+)
+
+func Test_TranslateError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode errlib.Code
+		wantSlug errlib.Slug
+	}{
+		{
+			name:     "No documents",
+			err:      fmt.Errorf("find user: %w", mongo.ErrNoDocuments),
+			wantCode: errlib.NotFoundCode,
+			wantSlug: errlib.SlugNotFound,
+		},
+		{
+			name:     "Duplicate key",
+			err:      mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}},
+			wantCode: errlib.ConflictCode,
+			wantSlug: errlib.SlugAlreadyExists,
+		},
+		{
+			name:     "Server selection",
+			err:      topology.ServerSelectionError{Wrapped: topology.ErrServerSelectionTimeout},
+			wantCode: errlib.InternalCode,
+			wantSlug: errlib.SlugDatabaseUnavailable,
+		},
+		{
+			name:     "Network error",
+			err:      mongo.CommandError{Labels: []string{"NetworkError"}},
+			wantCode: errlib.InternalCode,
+			wantSlug: errlib.SlugDatabaseUnavailable,
+		},
+		{
+			name:     "Context deadline exceeded",
+			err:      fmt.Errorf("find: %w", context.DeadlineExceeded),
+			wantCode: errlib.InternalCode,
+			wantSlug: errlib.SlugRequestCanceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := golibmongo.TranslateError(tt.err)
+
+			var appErr errlib.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, tt.wantCode, appErr.Code())
+			assert.Equal(t, tt.wantSlug, appErr.Slug())
+			assert.Equal(t, tt.err, errors.Unwrap(err), "the cause must be kept")
+		})
+	}
+}
+
+func Test_TranslateError_Unchanged(t *testing.T) {
+	assert.NoError(t, golibmongo.TranslateError(nil))
+
+	err := errors.New("some error")
+	assert.Equal(t, err, golibmongo.TranslateError(err))
+}
@@ -0,0 +1,49 @@
+package mongo
+
+import (
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kodenkai-labs/go-lib/filter"
+)
+
+var filterOperators = map[filter.Operator]string{
+	filter.OperatorEq:  "$eq",
+	filter.OperatorNe:  "$ne",
+	filter.OperatorGt:  "$gt",
+	filter.OperatorGte: "$gte",
+	filter.OperatorLt:  "$lt",
+	filter.OperatorLte: "$lte",
+	filter.OperatorIn:  "$in",
+	filter.OperatorNin: "$nin",
+}
+
+// FilterDocument converts the filter conditions to the query filter.
+// The sorting can be converted by SortDocument.
+func FilterDocument(f filter.Filter) bson.D {
+	conditions := make(bson.A, 0, len(f.Conditions))
+	for _, condition := range f.Conditions {
+		var expression bson.D
+		if condition.Operator == filter.OperatorContains {
+			value, _ := condition.Value.(string)
+			expression = bson.D{{Key: "$regex", Value: primitive.Regex{Pattern: regexp.QuoteMeta(value), Options: "i"}}}
+		} else {
+			value := condition.Value
+			if values, ok := value.([]any); ok {
+				value = bson.A(values)
+			}
+			expression = bson.D{{Key: filterOperators[condition.Operator], Value: value}}
+		}
+
+		conditions = append(conditions, bson.D{{Key: condition.Column, Value: expression}})
+	}
+
+	if len(conditions) == 0 {
+		return bson.D{}
+	}
+
+	// $and keeps several conditions on the same key
+	return bson.D{{Key: "$and", Value: conditions}}
+}
@@ -0,0 +1,340 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// defaultIndexName is the name of the index created by the server for every collection.
+const defaultIndexName = "_id_"
+
+// Index declares an index of a collection, see https://www.mongodb.com/docs/manual/indexes/
+type Index struct {
+	// Name is the name of the index. If empty, it's generated from the keys like the server does,
+	// e.g. "user_id_1_created_at_-1".
+	Name string
+
+	// Keys are the indexed fields in order with their types: 1 or -1 for the ascending or descending order,
+	// or a special type like "text" or "2dsphere". Several keys make a compound index.
+	Keys bson.D
+
+	// Unique rejects the documents with duplicate values of the keys.
+	Unique bool
+
+	// Sparse skips the documents without the indexed fields.
+	Sparse bool
+
+	// ExpireAfter makes a TTL index, which deletes the documents after the duration passes since
+	// the date of the indexed field. It can be used with a single date field only,
+	// and must be a whole number of seconds, since the server doesn't support fractions of a second.
+	ExpireAfter *time.Duration
+
+	// PartialFilter indexes only the documents matching the filter expression. The server normalizes it,
+	// so the equality conditions must use the explicit $eq operator, and the documents of several fields
+	// must be ordered, i.e. bson.D, to avoid the false drift reports.
+	PartialFilter any
+}
+
+// IndexName returns the name of the index, generated from the keys if it's not set.
+func (i Index) IndexName() string {
+	if i.Name != "" {
+		return i.Name
+	}
+	return keysString(i.Keys)
+}
+
+// Validate checks the options of the index which the server would misinterpret.
+func (i Index) Validate() error {
+	if len(i.Keys) == 0 {
+		return fmt.Errorf("index %s: no keys", i.IndexName())
+	}
+
+	if i.ExpireAfter != nil && (*i.ExpireAfter < 0 || *i.ExpireAfter%time.Second != 0) {
+		return fmt.Errorf("index %s: ttl %s isn't a non-negative whole number of seconds", i.IndexName(), *i.ExpireAfter)
+	}
+
+	return nil
+}
+
+// Model returns the index model to create the index. The index must be valid, see Validate.
+func (i Index) Model() mongo.IndexModel {
+	opts := options.Index().SetName(i.IndexName())
+	if i.Unique {
+		opts.SetUnique(true)
+	}
+	if i.Sparse {
+		opts.SetSparse(true)
+	}
+	if i.ExpireAfter != nil {
+		opts.SetExpireAfterSeconds(int32(i.ExpireAfter.Seconds()))
+	}
+	if i.PartialFilter != nil {
+		opts.SetPartialFilterExpression(i.PartialFilter)
+	}
+
+	return mongo.IndexModel{Keys: i.Keys, Options: opts}
+}
+
+// IndexReport describes the result of SyncIndexes.
+type IndexReport struct {
+	Collection string
+
+	// Created lists the declared indexes which were missing and have been created.
+	Created []string
+
+	// Changed lists the declared indexes which exist with different keys or options,
+	// or which aren't created, since their keys are already indexed under another name.
+	// They aren't changed automatically, since rebuilding an index can be expensive:
+	// drop them, e.g. in a migration, to be recreated by the next sync.
+	Changed []string
+
+	// Undeclared lists the existing indexes which aren't declared, e.g. the ones not used anymore.
+	Undeclared []string
+}
+
+// HasDrift reports whether the existing indexes differ from the declared ones.
+func (r *IndexReport) HasDrift() bool {
+	return len(r.Changed) > 0 || len(r.Undeclared) > 0
+}
+
+// IndexSyncer is implemented by Collection, so the indexes of the collections of different types
+// can be synced together by SyncAllIndexes.
+type IndexSyncer interface {
+	SyncIndexes(ctx context.Context) (*IndexReport, error)
+}
+
+// WithIndexes declares the indexes of the collection synced by Collection.SyncIndexes.
+func WithIndexes(indexes ...Index) CollectionOption {
+	return func(cfg *collectionConfig) {
+		cfg.indexes = append(cfg.indexes, indexes...)
+	}
+}
+
+// Indexes returns the declared indexes of the collection.
+func (c *Collection[T]) Indexes() []Index {
+	return c.cfg.indexes
+}
+
+// SyncIndexes creates the missing declared indexes of the collection and reports the drift, see SyncIndexes.
+func (c *Collection[T]) SyncIndexes(ctx context.Context) (*IndexReport, error) {
+	return SyncIndexes(ctx, c.coll, c.cfg.indexes...)
+}
+
+// SyncIndexes creates the declared indexes missing in the collection and reports the existing indexes
+// which differ from the declared ones or aren't declared. Neither of them is changed or dropped.
+func SyncIndexes(ctx context.Context, coll *mongo.Collection, indexes ...Index) (*IndexReport, error) {
+	for _, index := range indexes {
+		if err := index.Validate(); err != nil {
+			return nil, fmt.Errorf("sync indexes of %s: %w", coll.Name(), err)
+		}
+	}
+
+	cursor, err := coll.Indexes().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list indexes of %s: %w", coll.Name(), err)
+	}
+
+	var existing []existingIndex
+	if err = cursor.All(ctx, &existing); err != nil {
+		return nil, fmt.Errorf("decode indexes of %s: %w", coll.Name(), err)
+	}
+
+	report, missing := diffIndexes(indexes, existing)
+	report.Collection = coll.Name()
+
+	if len(missing) > 0 {
+		models := make([]mongo.IndexModel, 0, len(missing))
+		for _, index := range missing {
+			models = append(models, index.Model())
+		}
+
+		if _, err = coll.Indexes().CreateMany(ctx, models); err != nil {
+			return nil, fmt.Errorf("create indexes of %s: %w", coll.Name(), err)
+		}
+	}
+
+	return report, nil
+}
+
+// diffIndexes compares the declared indexes with the existing ones and returns the report
+// along with the indexes to be created.
+func diffIndexes(indexes []Index, existing []existingIndex) (*IndexReport, []Index) {
+	report := &IndexReport{}
+
+	existingByName := make(map[string]existingIndex, len(existing))
+	existingByKeys := make(map[string]string, len(existing))
+	for _, index := range existing {
+		existingByName[index.Name] = index
+		existingByKeys[index.keysString()] = index.Name
+	}
+
+	declared := make(map[string]bool, len(indexes))
+	var missing []Index
+	for _, index := range indexes {
+		name := index.IndexName()
+		declared[name] = true
+
+		current, ok := existingByName[name]
+		if ok {
+			if !current.matches(index) {
+				report.Changed = append(report.Changed, name)
+			}
+			continue
+		}
+
+		// the server rejects an index with the same keys as an existing one under another name
+		if _, ok = existingByKeys[normalizedKeys(index.Keys).String()]; ok {
+			report.Changed = append(report.Changed, name)
+			continue
+		}
+
+		missing = append(missing, index)
+		report.Created = append(report.Created, name)
+	}
+
+	for _, index := range existing {
+		if !declared[index.Name] && index.Name != defaultIndexName {
+			report.Undeclared = append(report.Undeclared, index.Name)
+		}
+	}
+
+	return report, missing
+}
+
+// SyncAllIndexes syncs the indexes of the collections, logging the created indexes and the drift.
+// It can be called at the service startup before service.Service.RunWait,
+// or from a migration registered with migration.WithMongoMigration.
+func SyncAllIndexes(ctx context.Context, collections ...IndexSyncer) ([]*IndexReport, error) {
+	reports := make([]*IndexReport, 0, len(collections))
+	for _, collection := range collections {
+		report, err := collection.SyncIndexes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+
+		logger := logrus.WithField("collection", report.Collection)
+		if len(report.Created) > 0 {
+			logger.WithField("indexes", report.Created).Info("created mongodb indexes")
+		}
+		if len(report.Changed) > 0 {
+			logger.WithField("indexes", report.Changed).Warn("mongodb indexes differ from the declared ones")
+		}
+		if len(report.Undeclared) > 0 {
+			logger.WithField("indexes", report.Undeclared).Warn("mongodb indexes are not declared")
+		}
+	}
+
+	return reports, nil
+}
+
+// existingIndex is the index specification returned by the server.
+type existingIndex struct {
+	Name                    string   `bson:"name"`
+	Key                     bson.D   `bson:"key"`
+	Unique                  bool     `bson:"unique"`
+	Sparse                  bool     `bson:"sparse"`
+	ExpireAfterSeconds      *int32   `bson:"expireAfterSeconds"`
+	PartialFilterExpression bson.Raw `bson:"partialFilterExpression"`
+
+	// Weights are the fields of a text index, whose keys are replaced by the server with _fts and _ftsx.
+	Weights bson.D `bson:"weights"`
+}
+
+// keysString returns the keys of the index, see indexKeys.String.
+func (e existingIndex) keysString() string {
+	return indexKeys{keys: e.Key, textFields: fieldNames(e.Weights)}.String()
+}
+
+func (e existingIndex) matches(index Index) bool {
+	if e.keysString() != normalizedKeys(index.Keys).String() || e.Unique != index.Unique || e.Sparse != index.Sparse {
+		return false
+	}
+
+	if (e.ExpireAfterSeconds == nil) != (index.ExpireAfter == nil) {
+		return false
+	}
+	if index.ExpireAfter != nil && *e.ExpireAfterSeconds != int32(index.ExpireAfter.Seconds()) {
+		return false
+	}
+
+	if (e.PartialFilterExpression == nil) != (index.PartialFilter == nil) {
+		return false
+	}
+	if index.PartialFilter != nil {
+		declared, err := bson.MarshalExtJSON(index.PartialFilter, false, false)
+		if err != nil {
+			return false
+		}
+		current, err := bson.MarshalExtJSON(e.PartialFilterExpression, false, false)
+		if err != nil {
+			return false
+		}
+		return string(declared) == string(current)
+	}
+
+	return true
+}
+
+// indexKeys are the keys of an index in the form reported by the server.
+type indexKeys struct {
+	keys       bson.D
+	textFields []string
+}
+
+// normalizedKeys converts the declared keys to the form reported by the server:
+// the fields of a text index are replaced with _fts and _ftsx keys and listed in the weights.
+func normalizedKeys(keys bson.D) indexKeys {
+	var normalized indexKeys
+	for _, key := range keys {
+		if key.Value != "text" {
+			normalized.keys = append(normalized.keys, key)
+			continue
+		}
+
+		if normalized.textFields == nil {
+			normalized.keys = append(normalized.keys, bson.E{Key: "_fts", Value: "text"}, bson.E{Key: "_ftsx", Value: 1})
+		}
+		normalized.textFields = append(normalized.textFields, key.Key)
+	}
+
+	return normalized
+}
+
+// String returns the keys in the format of the generated index names, e.g. "user_id_1_created_at_-1",
+// followed by the sorted text fields, if any.
+func (k indexKeys) String() string {
+	keys := keysString(k.keys)
+	if len(k.textFields) == 0 {
+		return keys
+	}
+
+	textFields := slices.Clone(k.textFields)
+	slices.Sort(textFields)
+	return keys + " " + strings.Join(textFields, ",")
+}
+
+// keysString returns the keys in the format of the generated index names, e.g. "user_id_1_created_at_-1".
+func keysString(keys bson.D) string {
+	parts := make([]string, 0, 2*len(keys))
+	for _, key := range keys {
+		parts = append(parts, key.Key, fmt.Sprint(key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+func fieldNames(document bson.D) []string {
+	names := make([]string, 0, len(document))
+	for _, element := range document {
+		names = append(names, element.Key)
+	}
+	return names
+}
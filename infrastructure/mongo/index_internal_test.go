@@ -0,0 +1,141 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// serverIndexes returns the indexes in the form returned by the listIndexes command.
+func serverIndexes(t *testing.T, specs ...bson.D) []existingIndex {
+	t.Helper()
+
+	idIndex := bson.D{{Key: "v", Value: 2}, {Key: "key", Value: bson.D{{Key: "_id", Value: 1}}}, {Key: "name", Value: "_id_"}}
+	specs = append([]bson.D{idIndex}, specs...)
+
+	indexes := make([]existingIndex, 0, len(specs))
+	for _, spec := range specs {
+		raw, err := bson.Marshal(spec)
+		require.NoError(t, err)
+
+		var index existingIndex
+		require.NoError(t, bson.Unmarshal(raw, &index))
+		indexes = append(indexes, index)
+	}
+
+	return indexes
+}
+
+func Test_diffIndexes(t *testing.T) {
+	ttl := time.Hour
+	longerTTL := 2 * time.Hour
+
+	emailIndex := bson.D{
+		{Key: "v", Value: 2},
+		{Key: "key", Value: bson.D{{Key: "email", Value: int32(1)}}},
+		{Key: "name", Value: "email_1"},
+		{Key: "unique", Value: true},
+	}
+	ttlIndex := bson.D{
+		{Key: "v", Value: 2},
+		{Key: "key", Value: bson.D{{Key: "expires_at", Value: int32(1)}}},
+		{Key: "name", Value: "expires_at_1"},
+		{Key: "expireAfterSeconds", Value: int32(3600)},
+	}
+	textIndex := bson.D{
+		{Key: "v", Value: 2},
+		{Key: "key", Value: bson.D{{Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}}},
+		{Key: "name", Value: "title_text_body_text"},
+		{Key: "weights", Value: bson.D{{Key: "body", Value: int32(1)}, {Key: "title", Value: int32(1)}}},
+		{Key: "default_language", Value: "english"},
+		{Key: "textIndexVersion", Value: int32(3)},
+	}
+	partialIndex := bson.D{
+		{Key: "v", Value: 2},
+		{Key: "key", Value: bson.D{{Key: "user_id", Value: int32(1)}, {Key: "created_at", Value: int32(-1)}}},
+		{Key: "name", Value: "active_by_user"},
+		{Key: "partialFilterExpression", Value: bson.D{{Key: "deleted", Value: bson.D{{Key: "$eq", Value: false}}}}},
+	}
+
+	tests := []struct {
+		name       string
+		declared   []Index
+		existing   []bson.D
+		wantReport *IndexReport
+	}{
+		{
+			name: "Missing indexes",
+			declared: []Index{
+				{Keys: bson.D{{Key: "email", Value: 1}}, Unique: true},
+				{Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfter: &ttl},
+			},
+			wantReport: &IndexReport{Created: []string{"email_1", "expires_at_1"}},
+		},
+		{
+			name: "Matching indexes",
+			declared: []Index{
+				{Keys: bson.D{{Key: "email", Value: 1}}, Unique: true},
+				{Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfter: &ttl},
+				{Keys: bson.D{{Key: "title", Value: "text"}, {Key: "body", Value: "text"}}},
+				{
+					Name:          "active_by_user",
+					Keys:          bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+					PartialFilter: bson.D{{Key: "deleted", Value: bson.D{{Key: "$eq", Value: false}}}},
+				},
+			},
+			existing:   []bson.D{emailIndex, ttlIndex, textIndex, partialIndex},
+			wantReport: &IndexReport{},
+		},
+		{
+			name: "Changed options",
+			declared: []Index{
+				{Keys: bson.D{{Key: "email", Value: 1}}},
+				{Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfter: &longerTTL},
+				{Name: "title_text_body_text", Keys: bson.D{{Key: "title", Value: "text"}}},
+				{
+					Name:          "active_by_user",
+					Keys:          bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+					PartialFilter: bson.D{{Key: "deleted", Value: bson.D{{Key: "$eq", Value: true}}}},
+				},
+			},
+			existing: []bson.D{emailIndex, ttlIndex, textIndex, partialIndex},
+			wantReport: &IndexReport{
+				Changed: []string{"email_1", "expires_at_1", "title_text_body_text", "active_by_user"},
+			},
+		},
+		{
+			name: "Same keys under another name",
+			declared: []Index{
+				{Name: "by_email", Keys: bson.D{{Key: "email", Value: 1}}, Unique: true},
+				{Name: "search", Keys: bson.D{{Key: "body", Value: "text"}, {Key: "title", Value: "text"}}},
+			},
+			existing: []bson.D{emailIndex, textIndex},
+			wantReport: &IndexReport{
+				Changed:    []string{"by_email", "search"},
+				Undeclared: []string{"email_1", "title_text_body_text"},
+			},
+		},
+		{
+			name:       "Undeclared indexes",
+			existing:   []bson.D{emailIndex},
+			wantReport: &IndexReport{Undeclared: []string{"email_1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, missing := diffIndexes(tt.declared, serverIndexes(t, tt.existing...))
+
+			assert.Equal(t, tt.wantReport, report)
+
+			missingNames := make([]string, 0, len(missing))
+			for _, index := range missing {
+				missingNames = append(missingNames, index.IndexName())
+			}
+			assert.ElementsMatch(t, tt.wantReport.Created, missingNames)
+		})
+	}
+}
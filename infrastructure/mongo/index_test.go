@@ -0,0 +1,115 @@
+package mongo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+
+	golibmongo "github.com/kodenkai-labs/go-lib/infrastructure/mongo"
+)
+
+func Test_Index_IndexName(t *testing.T) {
+	tests := []struct {
+		name  string
+		index golibmongo.Index
+		want  string
+	}{
+		{
+			name:  "Explicit name",
+			index: golibmongo.Index{Name: "by_email", Keys: bson.D{{Key: "email", Value: 1}}},
+			want:  "by_email",
+		},
+		{
+			name:  "Single key",
+			index: golibmongo.Index{Keys: bson.D{{Key: "email", Value: 1}}},
+			want:  "email_1",
+		},
+		{
+			name:  "Compound",
+			index: golibmongo.Index{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			want:  "user_id_1_created_at_-1",
+		},
+		{
+			name:  "Text",
+			index: golibmongo.Index{Keys: bson.D{{Key: "title", Value: "text"}}},
+			want:  "title_text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.index.IndexName())
+		})
+	}
+}
+
+func Test_Index_Model(t *testing.T) {
+	ttl := 24 * time.Hour
+	filter := bson.D{{Key: "deleted", Value: bson.D{{Key: "$eq", Value: false}}}}
+
+	model := golibmongo.Index{
+		Keys:          bson.D{{Key: "expires_at", Value: 1}},
+		Unique:        true,
+		ExpireAfter:   &ttl,
+		PartialFilter: filter,
+	}.Model()
+
+	assert.Equal(t, bson.D{{Key: "expires_at", Value: 1}}, model.Keys)
+	assert.Equal(t, "expires_at_1", *model.Options.Name)
+	assert.True(t, *model.Options.Unique)
+	assert.Nil(t, model.Options.Sparse)
+	assert.Equal(t, int32(86400), *model.Options.ExpireAfterSeconds)
+	assert.Equal(t, filter, model.Options.PartialFilterExpression)
+}
+
+func Test_Index_Validate(t *testing.T) {
+	ttl := func(d time.Duration) *time.Duration { return &d }
+
+	tests := []struct {
+		name    string
+		index   golibmongo.Index
+		wantErr bool
+	}{
+		{
+			name:  "Valid ttl",
+			index: golibmongo.Index{Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfter: ttl(time.Hour)},
+		},
+		{
+			name:  "Zero ttl",
+			index: golibmongo.Index{Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfter: ttl(0)},
+		},
+		{
+			name:    "Sub-second ttl",
+			index:   golibmongo.Index{Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfter: ttl(500 * time.Millisecond)},
+			wantErr: true,
+		},
+		{
+			name:    "Fractional ttl",
+			index:   golibmongo.Index{Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfter: ttl(1500 * time.Millisecond)},
+			wantErr: true,
+		},
+		{
+			name:    "Negative ttl",
+			index:   golibmongo.Index{Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfter: ttl(-time.Second)},
+			wantErr: true,
+		},
+		{
+			name:    "No keys",
+			index:   golibmongo.Index{Name: "empty"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.index.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
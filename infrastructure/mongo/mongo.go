@@ -10,7 +10,6 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
-	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 type DBMode int
@@ -23,26 +22,121 @@ const (
 const timeout = 5 * time.Second
 
 func New(cfg Config, mode DBMode) (*mongo.Database, error) {
-	logrus.Info("Connecting to mongodb")
-
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	cOpts := options.Client().
-		ApplyURI(cfg.URI).
-		SetMinPoolSize(cfg.MinPoolSize).
-		SetMaxConnIdleTime(cfg.MaxConnIdleTime)
+	cOpts, err := cfg.ClientOptions()
+	if err != nil {
+		return nil, err
+	}
 
 	switch mode {
 	case DBModeWrite:
-		// https://www.mongodb.com/docs/manual/reference/write-concern/
-		cOpts = cOpts.SetWriteConcern(writeconcern.W1())
+		// the write concern is set by ClientOptions, w:1 unless configured otherwise
 	case DBModeRead:
-		cOpts = cOpts.SetReadPreference(readpref.Secondary())
+		readPref, err := cfg.ReadPreference.build()
+		if err != nil {
+			return nil, fmt.Errorf("read preference: %w", err)
+		}
+		cOpts = cOpts.SetReadPreference(readPref)
 	default:
 		return nil, fmt.Errorf("unknown DBMode: %v", mode)
 	}
 
+	client, err := connect(cOpts, cfg.connectTimeout())
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Database(cfg.Name), nil
+}
+
+// DB holds the connected client and provides its database for writes and reads.
+// It implements service.StartStopper, so the client is disconnected when the service stops.
+type DB struct {
+	client *mongo.Client
+	write  *mongo.Database
+	read   *mongo.Database
+}
+
+// NewDB connects the client and returns the DB using it for both the write and read databases.
+// Unless configured otherwise, reads of the read database are served by the secondaries.
+func NewDB(cfg Config) (*DB, error) {
+	cOpts, err := cfg.ClientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	readPref, err := cfg.ReadPreference.build()
+	if err != nil {
+		return nil, fmt.Errorf("read preference: %w", err)
+	}
+
+	client, err := connect(cOpts, cfg.connectTimeout())
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{
+		client: client,
+		write:  client.Database(cfg.Name),
+		read:   client.Database(cfg.Name, options.Database().SetReadPreference(readPref)),
+	}, nil
+}
+
+// Client returns the connected client, e.g. to start sessions.
+func (db *DB) Client() *mongo.Client {
+	return db.client
+}
+
+// Write returns the database for writes and reads that must see the latest writes.
+func (db *DB) Write() *mongo.Database {
+	return db.write
+}
+
+// Read returns the database for reads that can tolerate the replication lag.
+func (db *DB) Read() *mongo.Database {
+	return db.read
+}
+
+// HealthCheck pings the primary.
+func (db *DB) HealthCheck() error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := db.client.Ping(ctx, readpref.Primary()); err != nil {
+		return fmt.Errorf("ping mongo: %w", err)
+	}
+	return nil
+}
+
+// Close disconnects the client, waiting for the in-progress operations to finish.
+func (db *DB) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := db.client.Disconnect(ctx); err != nil {
+		return fmt.Errorf("disconnect mongo: %w", err)
+	}
+	return nil
+}
+
+// Start does nothing, since the client is connected by NewDB.
+func (db *DB) Start() {}
+
+// Stop disconnects the client.
+func (db *DB) Stop() {
+	if err := db.Close(); err != nil {
+		logrus.WithError(err).Error("failed to close mongodb connection")
+		return
+	}
+
+	logrus.Info("mongodb connection closed")
+}
+
+func connect(cOpts *options.ClientOptions, timeout time.Duration) (*mongo.Client, error) {
+	logrus.Info("Connecting to mongodb")
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
 	client, err := mongo.Connect(ctx, cOpts)
 	if err != nil {
 		return nil, fmt.Errorf("connect to mongo: %w", err)
@@ -52,10 +146,11 @@ func New(cfg Config, mode DBMode) (*mongo.Database, error) {
 	defer cancel()
 
 	if err = client.Ping(ctx, readpref.Primary()); err != nil {
+		_ = client.Disconnect(context.Background())
 		return nil, fmt.Errorf("ping mongo: %w", err)
 	}
 
 	logrus.Info("Successfully established connection to mongodb")
 
-	return client.Database(cfg.Name), nil
+	return client, nil
 }
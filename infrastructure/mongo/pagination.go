@@ -0,0 +1,74 @@
+package mongo
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/kodenkai-labs/go-lib/pagination"
+)
+
+// SortDocument converts the sort fields to the sort document.
+func SortDocument(sort []pagination.SortField) bson.D {
+	doc := make(bson.D, 0, len(sort))
+	for _, field := range sort {
+		doc = append(doc, bson.E{Key: field.Field, Value: sortOrder(field.Desc)})
+	}
+
+	return doc
+}
+
+// KeysetFilter returns the filter selecting the documents of the keyset pagination request page.
+// It must be combined with the other query conditions using $and.
+func KeysetFilter(req pagination.Keyset) bson.D {
+	if len(req.After) == 0 {
+		return bson.D{}
+	}
+
+	// (a > x) OR (a = x AND b > y) OR ...
+	alternatives := make(bson.A, 0, len(req.Sort))
+	for i, field := range req.Sort {
+		conditions := make(bson.D, 0, i+1)
+		for j := range i {
+			conditions = append(conditions, bson.E{Key: req.Sort[j].Field, Value: req.After[j]})
+		}
+
+		operator := "$gt"
+		if field.Desc != req.Backward {
+			operator = "$lt"
+		}
+		conditions = append(conditions, bson.E{Key: field.Field, Value: bson.D{{Key: operator, Value: req.After[i]}}})
+
+		alternatives = append(alternatives, conditions)
+	}
+
+	return bson.D{{Key: "$or", Value: alternatives}}
+}
+
+// KeysetFindOptions returns the find options of the keyset pagination request page.
+// It fetches one extra document to detect whether there are more documents, as expected by pagination.NewKeysetPage.
+func KeysetFindOptions(req pagination.Keyset) *options.FindOptions {
+	// a backward page is fetched in the reversed order and reversed back by pagination.NewKeysetPage
+	sort := make(bson.D, 0, len(req.Sort))
+	for _, field := range req.Sort {
+		sort = append(sort, bson.E{Key: field.Field, Value: sortOrder(field.Desc != req.Backward)})
+	}
+
+	return options.Find().SetSort(sort).SetLimit(int64(req.Limit + 1))
+}
+
+// OffsetFindOptions returns the find options of the offset pagination request page.
+func OffsetFindOptions(req pagination.Offset) *options.FindOptions {
+	opts := options.Find().SetLimit(int64(req.Limit)).SetSkip(int64(req.Offset))
+	if len(req.Sort) > 0 {
+		opts = opts.SetSort(SortDocument(req.Sort))
+	}
+
+	return opts
+}
+
+func sortOrder(desc bool) int {
+	if desc {
+		return -1
+	}
+	return 1
+}
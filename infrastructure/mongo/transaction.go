@@ -0,0 +1,168 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/kodenkai-labs/go-lib/internal/backoff"
+)
+
+// Error labels of the errors after which the transaction or its commit can be safely retried,
+// see https://www.mongodb.com/docs/manual/core/transactions-in-applications/
+const (
+	labelTransientTransactionError      = "TransientTransactionError"
+	labelUnknownTransactionCommitResult = "UnknownTransactionCommitResult"
+)
+
+var transactionRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "mongo_transaction_retries_total",
+	Help: "Number of transaction and commit retries caused by transient errors and unknown commit results.",
+}, []string{"label"})
+
+// TrxContextGetter is implemented by DB, it allows mocking transactions in the repositories.
+type TrxContextGetter interface {
+	Transaction(ctx context.Context, fc func(ctx context.Context) error, opts ...TxOption) error
+}
+
+type sessionKey struct{}
+
+var trxKey = &sessionKey{}
+
+// RetryOptions represents the retry policy of Transaction.
+// MaxRetries limits the retries of the transaction, and separately of its commit.
+type RetryOptions = backoff.Options
+
+// TxOptions represents the options of a transaction started by Transaction.
+type TxOptions struct {
+	// Transaction sets the read concern, write concern and read preference of the transaction.
+	// If nil, the ones of the session are used.
+	Transaction *options.TransactionOptions
+
+	Retry *RetryOptions
+}
+
+// TxOption represents a function that configures a transaction.
+type TxOption func(txOpts *TxOptions)
+
+// WithTransactionOptions sets the read concern, write concern and read preference of the transaction.
+func WithTransactionOptions(opts *options.TransactionOptions) TxOption {
+	return func(txOpts *TxOptions) {
+		txOpts.Transaction = opts
+	}
+}
+
+// WithRetry sets the retry policy of the transaction.
+// If not provided, 3 retries with backoff from 10ms up to 1s are used by default.
+func WithRetry(opts RetryOptions) TxOption {
+	return func(txOpts *TxOptions) {
+		txOpts.Retry = &opts
+	}
+}
+
+// InTransaction reports whether the context carries a transaction started by Transaction.
+func InTransaction(ctx context.Context) bool {
+	return SessionFrom(ctx) != nil
+}
+
+// SessionFrom returns the session of the transaction carried by the context, or nil if there is none.
+// The operations called with the context run in the transaction automatically,
+// so the session is only needed to inspect or control it directly.
+func SessionFrom(ctx context.Context) mongo.Session {
+	session, _ := ctx.Value(trxKey).(mongo.Session)
+	return session
+}
+
+// IsTransientError reports whether err is labeled as a transient transaction error,
+// after which the whole transaction can be safely retried.
+func IsTransientError(err error) bool {
+	return hasErrorLabel(err, labelTransientTransactionError)
+}
+
+func hasErrorLabel(err error, label string) bool {
+	var labeled interface{ HasErrorLabel(label string) bool }
+	return errors.As(err, &labeled) && labeled.HasErrorLabel(label)
+}
+
+// Transaction runs fc in a transaction of a new session. The context passed to fc carries the session,
+// so the operations called with it run in the transaction, and nested calls of Transaction join it.
+// The transaction is committed if fc returns nil and aborted otherwise.
+// The whole transaction is rerun when it fails with a transient transaction error, e.g. a write conflict,
+// waiting an exponentially growing jittered delay between attempts, so fc must be safe to run several times.
+// The commit is retried with the same delays when its result is unknown, e.g. after a network error.
+// Transactions require a replica set or a sharded cluster.
+func (db *DB) Transaction(ctx context.Context, fc func(ctx context.Context) error, opts ...TxOption) error {
+	if InTransaction(ctx) {
+		return fc(ctx)
+	}
+
+	txOpts := TxOptions{}
+	for _, opt := range opts {
+		opt(&txOpts)
+	}
+
+	retry := backoff.Default()
+	if txOpts.Retry != nil {
+		retry = *txOpts.Retry
+	}
+
+	session, err := db.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("start session: %w", err)
+	}
+	defer session.EndSession(context.WithoutCancel(ctx))
+
+	for attempt := 0; ; attempt++ {
+		err = runTransaction(ctx, session, fc, txOpts.Transaction, retry)
+		if !IsTransientError(err) || attempt >= retry.MaxRetries {
+			return err
+		}
+
+		transactionRetries.WithLabelValues(labelTransientTransactionError).Inc()
+
+		if waitErr := retry.Wait(ctx, attempt); waitErr != nil {
+			return errors.Join(err, waitErr)
+		}
+	}
+}
+
+func runTransaction(
+	ctx context.Context,
+	session mongo.Session,
+	fc func(ctx context.Context) error,
+	trxOpts *options.TransactionOptions,
+	retry RetryOptions,
+) error {
+	if err := session.StartTransaction(trxOpts); err != nil {
+		return fmt.Errorf("start transaction: %w", err)
+	}
+
+	sessionCtx := context.WithValue(mongo.NewSessionContext(ctx, session), trxKey, session)
+
+	if err := fc(sessionCtx); err != nil {
+		// the transaction is aborted by the server anyway if the abort fails
+		_ = session.AbortTransaction(context.WithoutCancel(sessionCtx))
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := session.CommitTransaction(sessionCtx)
+		if !hasErrorLabel(err, labelUnknownTransactionCommitResult) || attempt >= retry.MaxRetries {
+			if err != nil {
+				return fmt.Errorf("commit transaction: %w", err)
+			}
+			return nil
+		}
+
+		transactionRetries.WithLabelValues(labelUnknownTransactionCommitResult).Inc()
+
+		if waitErr := retry.Wait(ctx, attempt); waitErr != nil {
+			return errors.Join(fmt.Errorf("commit transaction: %w", err), waitErr)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func Test_DB_Transaction(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	transientError := mtest.CreateCommandErrorResponse(mtest.CommandError{
+		Code:    112,
+		Name:    "WriteConflict",
+		Message: "write conflict",
+		Labels:  []string{labelTransientTransactionError},
+	})
+	unknownCommitResult := mtest.CreateCommandErrorResponse(mtest.CommandError{
+		Code:    50,
+		Name:    "MaxTimeMSExpired",
+		Message: "operation exceeded time limit",
+		Labels:  []string{labelUnknownTransactionCommitResult},
+	})
+
+	tests := []struct {
+		name         string
+		responses    []bson.D
+		fcErr        error
+		wantErr      bool
+		wantCalls    int
+		wantCommands []string
+	}{
+		{
+			name:         "Committed",
+			responses:    []bson.D{mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse()},
+			wantCalls:    1,
+			wantCommands: []string{"insert", "commitTransaction"},
+		},
+		{
+			name:         "Aborted",
+			responses:    []bson.D{mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse()},
+			fcErr:        errFailed,
+			wantErr:      true,
+			wantCalls:    1,
+			wantCommands: []string{"insert", "abortTransaction"},
+		},
+		{
+			name: "Transient error retried",
+			responses: []bson.D{
+				transientError, mtest.CreateSuccessResponse(),
+				mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse(),
+			},
+			wantCalls:    2,
+			wantCommands: []string{"insert", "abortTransaction", "insert", "commitTransaction"},
+		},
+		{
+			name: "Transient error retries exhausted",
+			responses: []bson.D{
+				transientError, mtest.CreateSuccessResponse(),
+				transientError, mtest.CreateSuccessResponse(),
+				transientError, mtest.CreateSuccessResponse(),
+			},
+			wantErr:   true,
+			wantCalls: 3,
+			wantCommands: []string{
+				"insert", "abortTransaction", "insert", "abortTransaction", "insert", "abortTransaction",
+			},
+		},
+		{
+			name: "Unknown commit result retried",
+			responses: []bson.D{
+				mtest.CreateSuccessResponse(), unknownCommitResult, mtest.CreateSuccessResponse(),
+			},
+			wantCalls:    1,
+			wantCommands: []string{"insert", "commitTransaction", "commitTransaction"},
+		},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tt.responses...)
+			db := &DB{client: mt.Client, write: mt.DB, read: mt.DB}
+
+			calls := 0
+			err := db.Transaction(context.Background(), func(ctx context.Context) error {
+				calls++
+				if _, err := db.Write().Collection("users").InsertOne(ctx, bson.D{{Key: "name", Value: "name"}}); err != nil {
+					return err
+				}
+				return tt.fcErr
+			}, WithRetry(RetryOptions{MaxRetries: 2}))
+
+			if tt.wantErr {
+				assert.Error(mt, err)
+			} else {
+				assert.NoError(mt, err)
+			}
+			assert.Equal(mt, tt.wantCalls, calls)
+
+			var commands []string
+			for _, event := range mt.GetAllStartedEvents() {
+				commands = append(commands, event.CommandName)
+			}
+			assert.Equal(mt, tt.wantCommands, commands)
+		})
+	}
+}
+
+func Test_DB_Transaction_Joined(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("Nested transaction joins the outer one", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		db := &DB{client: mt.Client, write: mt.DB, read: mt.DB}
+
+		var outer, inner mongo.Session
+		err := db.Transaction(context.Background(), func(ctx context.Context) error {
+			outer = SessionFrom(ctx)
+			return db.Transaction(ctx, func(ctx context.Context) error {
+				inner = SessionFrom(ctx)
+				return nil
+			})
+		})
+
+		assert.NoError(mt, err)
+		assert.NotNil(mt, outer)
+		assert.Same(mt, outer, inner)
+	})
+}
@@ -0,0 +1,87 @@
+package mongo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+
+	golibmongo "github.com/kodenkai-labs/go-lib/infrastructure/mongo"
+)
+
+func Test_IsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "Write conflict",
+			err:  mongo.CommandError{Code: 112, Labels: []string{"TransientTransactionError"}},
+			want: true,
+		},
+		{
+			name: "Wrapped transient error",
+			err:  fmt.Errorf("insert: %w", mongo.CommandError{Labels: []string{"TransientTransactionError"}}),
+			want: true,
+		},
+		{
+			name: "Unknown commit result",
+			err:  mongo.CommandError{Labels: []string{"UnknownTransactionCommitResult"}},
+			want: false,
+		},
+		{
+			name: "Duplicate key",
+			err:  mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}},
+			want: false,
+		},
+		{
+			name: "Not a mongo error",
+			err:  errors.New("some error"),
+			want: false,
+		},
+		{
+			name: "Nil error",
+			err:  nil,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, golibmongo.IsTransientError(tt.err))
+		})
+	}
+}
+
+func Test_InTransaction(t *testing.T) {
+	assert.False(t, golibmongo.InTransaction(context.Background()))
+	assert.Nil(t, golibmongo.SessionFrom(context.Background()))
+}
+
+func Test_IsTransientError_Translated(t *testing.T) {
+	err := golibmongo.TranslateError(mongo.CommandError{Labels: []string{"NetworkError", "TransientTransactionError"}})
+	assert.True(t, golibmongo.IsTransientError(err))
+}
+
+func Test_TxOptions(t *testing.T) {
+	trxOpts := options.Transaction().SetReadConcern(readconcern.Snapshot())
+	retry := golibmongo.RetryOptions{MaxRetries: 5, MinBackoff: time.Millisecond, MaxBackoff: time.Second}
+
+	var txOpts golibmongo.TxOptions
+	for _, opt := range []golibmongo.TxOption{
+		golibmongo.WithTransactionOptions(trxOpts),
+		golibmongo.WithRetry(retry),
+	} {
+		opt(&txOpts)
+	}
+
+	assert.Equal(t, trxOpts, txOpts.Transaction)
+	assert.Equal(t, &retry, txOpts.Retry)
+}
@@ -0,0 +1,67 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/outbox"
+	"github.com/kodenkai-labs/go-lib/internal/sqltest"
+)
+
+// newFakeDB returns a fake database whose first select returns the pending messages, and inserts return the id 1.
+func newFakeDB(pending ...outbox.Message) *sqltest.DB {
+	var once sync.Once
+
+	return &sqltest.DB{
+		Query: func(_ int, query string, _ []any) (driver.Rows, error) {
+			if strings.HasPrefix(query, "INSERT") {
+				return sqltest.NewRows([]string{"id"}, []driver.Value{int64(1)}), nil
+			}
+
+			var values [][]driver.Value
+			once.Do(func() {
+				for _, msg := range pending {
+					values = append(values, []driver.Value{
+						int64(msg.ID), msg.Topic, msg.Key, msg.Payload, int64(msg.Attempts), msg.LastError,
+						msg.NextAttemptAt, msg.CreatedAt, nil,
+					})
+				}
+			})
+
+			return sqltest.NewRows([]string{
+				"id", "topic", "key", "payload", "attempts", "last_error", "next_attempt_at", "created_at", "delivered_at",
+			}, values...), nil
+		},
+	}
+}
+
+type transactionKey struct{}
+
+// fakeGetter implements outbox.DBGetter without the replicas and retries of postgres.DBGetter.
+type fakeGetter struct {
+	db *gorm.DB
+}
+
+func newFakeGetter(t *testing.T, db *sqltest.DB) *fakeGetter {
+	t.Helper()
+
+	return &fakeGetter{db: db.OpenGorm(t)}
+}
+
+func (g *fakeGetter) DBFrom(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(transactionKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return g.db.WithContext(ctx)
+}
+
+func (g *fakeGetter) Transaction(ctx context.Context, fc func(ctx context.Context) error) error {
+	return g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fc(context.WithValue(ctx, transactionKey{}, tx))
+	})
+}
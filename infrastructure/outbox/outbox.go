@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/postgres"
+)
+
+const defaultTable = "outbox_messages"
+
+// Message is an event stored in the outbox table.
+// The table is expected to be created by the application migrations:
+// ```
+//
+//	CREATE TABLE outbox_messages (
+//		id              BIGSERIAL PRIMARY KEY,
+//		topic           TEXT        NOT NULL,
+//		key             TEXT        NOT NULL DEFAULT '',
+//		payload         BYTEA       NOT NULL,
+//		attempts        INTEGER     NOT NULL DEFAULT 0,
+//		last_error      TEXT        NOT NULL DEFAULT '',
+//		next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+//		created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+//		delivered_at    TIMESTAMPTZ
+//	);
+//	CREATE INDEX outbox_messages_pending_idx ON outbox_messages (next_attempt_at) WHERE delivered_at IS NULL;
+//
+// ```
+type Message struct {
+	ID            uint64 `gorm:"primaryKey"`
+	Topic         string
+	Key           string
+	Payload       []byte
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	DeliveredAt   *time.Time
+}
+
+// Outbox records messages in the outbox table.
+type Outbox struct {
+	getter postgres.DBContextGetter
+	table  string
+}
+
+// Option represents a function that configures an Outbox.
+type Option func(*Outbox)
+
+// WithTable sets a custom outbox table name.
+// If not provided, the default table "outbox_messages" will be used.
+func WithTable(table string) Option {
+	return func(o *Outbox) {
+		o.table = table
+	}
+}
+
+// New creates a new Outbox.
+func New(getter postgres.DBContextGetter, opts ...Option) *Outbox {
+	o := &Outbox{
+		getter: getter,
+		table:  defaultTable,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// Record stores the message in the outbox table.
+// When called inside postgres.DBGetter.Transaction, the message is stored in the same transaction as the business data,
+// so it is published only if the transaction is committed.
+func (o *Outbox) Record(ctx context.Context, topic, key string, payload []byte) error {
+	now := time.Now()
+	msg := Message{
+		Topic:         topic,
+		Key:           key,
+		Payload:       payload,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+
+	if err := o.getter.DBFrom(ctx).Table(o.table).Create(&msg).Error; err != nil {
+		return fmt.Errorf("record outbox message: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package outbox_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/outbox"
+)
+
+func Test_Outbox_Record(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []outbox.Option
+		wantTable string
+	}{
+		{
+			name:      "Default table",
+			wantTable: `"outbox_messages"`,
+		},
+		{
+			name:      "Custom table",
+			opts:      []outbox.Option{outbox.WithTable("events")},
+			wantTable: `"events"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newFakeDB()
+			o := outbox.New(newFakeGetter(t, db), tt.opts...)
+
+			require.NoError(t, o.Record(context.Background(), "users", "42", []byte(`{"id":42}`)))
+
+			inserts := db.QueriesWithPrefix("INSERT INTO " + tt.wantTable)
+			require.Len(t, inserts, 1)
+			assert.Contains(t, inserts[0].Args, "users")
+			assert.Contains(t, inserts[0].Args, "42")
+			assert.Contains(t, inserts[0].Args, []byte(`{"id":42}`))
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/postgres"
+	"github.com/kodenkai-labs/go-lib/internal/backoff"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+	defaultMinBackoff   = time.Second
+	defaultMaxBackoff   = 5 * time.Minute
+)
+
+// Publisher publishes outbox messages to the message broker.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// DBGetter provides the database connections and transactions used by the Relay.
+// It's implemented by postgres.DBGetter.
+type DBGetter interface {
+	postgres.DBContextGetter
+	postgres.TrxContextGetter
+}
+
+// Relay polls pending messages from the outbox table and publishes them.
+// Rows are locked with FOR UPDATE SKIP LOCKED, so several replicas can run the Relay concurrently.
+// Messages are delivered at least once: if the transaction marking a message as delivered fails,
+// the message is published again.
+// Relay implements service.StartStopper, so it can be run alongside other services.
+type Relay struct {
+	getter    DBGetter
+	publisher Publisher
+
+	table        string
+	pollInterval time.Duration
+	batchSize    int
+	maxAttempts  int
+	backoff      backoff.Options
+
+	mu      sync.Mutex
+	started bool
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	// ctx is cancelled by Stop to interrupt the publishing in progress.
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// RelayOption represents a function that configures a Relay.
+type RelayOption func(*Relay)
+
+// WithRelayTable sets a custom outbox table name.
+// If not provided, the default table "outbox_messages" will be used.
+func WithRelayTable(table string) RelayOption {
+	return func(r *Relay) {
+		r.table = table
+	}
+}
+
+// WithPollInterval sets how often the Relay polls pending messages.
+// If not provided, 1 second is used by default.
+func WithPollInterval(interval time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.pollInterval = interval
+	}
+}
+
+// WithBatchSize sets the maximum number of messages published in one poll.
+// If not provided, 100 is used by default.
+func WithBatchSize(size int) RelayOption {
+	return func(r *Relay) {
+		r.batchSize = size
+	}
+}
+
+// WithMaxAttempts sets the number of publish attempts after which the message is no longer retried.
+// If not provided, messages are retried until they are published.
+func WithMaxAttempts(attempts int) RelayOption {
+	return func(r *Relay) {
+		r.maxAttempts = attempts
+	}
+}
+
+// WithBackoff sets the delay before the first retry and the upper limit of the exponentially growing delay.
+// If not provided, 1 second and 5 minutes are used by default.
+func WithBackoff(minBackoff, maxBackoff time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.backoff = backoff.Options{MinBackoff: minBackoff, MaxBackoff: maxBackoff}
+	}
+}
+
+// NewRelay creates a new Relay publishing messages through the given publisher.
+func NewRelay(getter DBGetter, publisher Publisher, opts ...RelayOption) *Relay {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := &Relay{
+		getter:       getter,
+		publisher:    publisher,
+		table:        defaultTable,
+		pollInterval: defaultPollInterval,
+		batchSize:    defaultBatchSize,
+		backoff:      backoff.Options{MinBackoff: defaultMinBackoff, MaxBackoff: defaultMaxBackoff},
+		ctx:          ctx,
+		cancel:       cancel,
+		done:         make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Start starts polling in the background. Only the first call has an effect,
+// and polling isn't started once the Relay is stopped.
+func (r *Relay) Start() {
+	r.startOnce.Do(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if r.ctx.Err() != nil {
+			return
+		}
+
+		r.started = true
+		go r.run()
+	})
+}
+
+// Stop stops polling and cancels the context of the publishing in progress.
+// The messages whose publishing is interrupted stay pending and are published again later.
+// It's safe to call it several times and before Start.
+func (r *Relay) Stop() {
+	r.stopOnce.Do(func() {
+		r.mu.Lock()
+		r.cancel()
+		started := r.started
+		r.mu.Unlock()
+
+		if started {
+			<-r.done
+		}
+
+		logrus.WithField("table", r.table).Info("outbox relay stopped")
+	})
+}
+
+func (r *Relay) run() {
+	defer close(r.done)
+
+	logrus.WithField("table", r.table).Info("starting outbox relay")
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.relay(r.ctx); err != nil && r.ctx.Err() == nil {
+			logrus.WithError(err).Error("relay outbox messages")
+		}
+	}
+}
+
+// relay publishes one batch of pending messages.
+func (r *Relay) relay(ctx context.Context) error {
+	return r.getter.Transaction(ctx, func(ctx context.Context) error {
+		db := r.getter.DBFrom(ctx)
+
+		query := db.Table(r.table).
+			Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}).
+			Where("delivered_at IS NULL AND next_attempt_at <= ?", time.Now()).
+			Order("id").
+			Limit(r.batchSize)
+		if r.maxAttempts > 0 {
+			query = query.Where("attempts < ?", r.maxAttempts)
+		}
+
+		var messages []Message
+		if err := query.Find(&messages).Error; err != nil {
+			return fmt.Errorf("select pending messages: %w", err)
+		}
+
+		for _, msg := range messages {
+			if err := r.publish(ctx, db, msg); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *Relay) publish(ctx context.Context, db *gorm.DB, msg Message) error {
+	var updates map[string]any
+
+	if err := r.publisher.Publish(ctx, msg); err != nil {
+		// the interrupted attempt isn't counted, the transaction is rolled back
+		if ctx.Err() != nil {
+			return fmt.Errorf("publish outbox message %d: %w", msg.ID, err)
+		}
+
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"id":       msg.ID,
+			"topic":    msg.Topic,
+			"attempts": msg.Attempts + 1,
+		}).Error("publish outbox message")
+
+		updates = map[string]any{
+			"attempts":        msg.Attempts + 1,
+			"last_error":      err.Error(),
+			"next_attempt_at": time.Now().Add(r.backoff.Limit(msg.Attempts)),
+		}
+	} else {
+		updates = map[string]any{
+			"attempts":     msg.Attempts + 1,
+			"delivered_at": time.Now(),
+		}
+	}
+
+	if err := db.Table(r.table).Where("id = ?", msg.ID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("update outbox message %d: %w", msg.ID, err)
+	}
+
+	return nil
+}
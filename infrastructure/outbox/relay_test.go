@@ -0,0 +1,138 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/outbox"
+)
+
+// fakePublisher records the published messages and fails the ones of the failing topic.
+type fakePublisher struct {
+	mu        sync.Mutex
+	published []uint64
+	failTopic string
+}
+
+func (p *fakePublisher) Publish(_ context.Context, msg outbox.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.published = append(p.published, msg.ID)
+	if msg.Topic == p.failTopic {
+		return errors.New("broker is unavailable")
+	}
+	return nil
+}
+
+func (p *fakePublisher) publishedIDs() []uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]uint64(nil), p.published...)
+}
+
+// blockingPublisher blocks until the context is cancelled.
+type blockingPublisher struct {
+	started chan struct{}
+}
+
+func (p *blockingPublisher) Publish(ctx context.Context, _ outbox.Message) error {
+	close(p.started)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func Test_Relay(t *testing.T) {
+	now := time.Now()
+	db := newFakeDB(
+		outbox.Message{ID: 1, Topic: "users", Payload: []byte("1"), NextAttemptAt: now, CreatedAt: now},
+		outbox.Message{ID: 2, Topic: "orders", Payload: []byte("2"), Attempts: 2, NextAttemptAt: now, CreatedAt: now},
+	)
+	publisher := &fakePublisher{failTopic: "orders"}
+
+	relay := outbox.NewRelay(newFakeGetter(t, db), publisher, outbox.WithPollInterval(10*time.Millisecond))
+	relay.Start()
+	require.Eventually(t, func() bool {
+		return len(db.QueriesWithPrefix("COMMIT")) > 0
+	}, time.Second, 10*time.Millisecond)
+	relay.Stop()
+
+	assert.Equal(t, []uint64{1, 2}, publisher.publishedIDs())
+
+	selects := db.QueriesWithPrefix("SELECT")
+	require.NotEmpty(t, selects)
+	assert.Contains(t, selects[0].Query, "FOR UPDATE SKIP LOCKED")
+
+	updates := db.QueriesWithPrefix("UPDATE")
+	require.Len(t, updates, 2)
+
+	delivered := updates[0]
+	assert.Contains(t, delivered.Query, `"delivered_at"`)
+	assert.Equal(t, int64(1), delivered.Args[0], "attempts")
+	assert.Equal(t, int64(1), delivered.Args[len(delivered.Args)-1], "id")
+
+	failed := updates[1]
+	assert.NotContains(t, failed.Query, `"delivered_at"`)
+	assert.Equal(t, int64(3), failed.Args[0], "attempts")
+	assert.Equal(t, "broker is unavailable", failed.Args[1], "last_error")
+	assert.Equal(t, int64(2), failed.Args[len(failed.Args)-1], "id")
+}
+
+func Test_Relay_Stop(t *testing.T) {
+	now := time.Now()
+	db := newFakeDB(
+		outbox.Message{ID: 1, Topic: "users", Payload: []byte("1"), NextAttemptAt: now, CreatedAt: now},
+	)
+	publisher := &blockingPublisher{started: make(chan struct{})}
+
+	relay := outbox.NewRelay(newFakeGetter(t, db), publisher, outbox.WithPollInterval(10*time.Millisecond))
+	relay.Start()
+	<-publisher.started
+
+	stopped := make(chan struct{})
+	go func() {
+		relay.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop didn't cancel the hung publishing")
+	}
+
+	// the interrupted attempt isn't recorded
+	assert.Empty(t, db.QueriesWithPrefix("UPDATE"))
+	assert.Empty(t, db.QueriesWithPrefix("COMMIT"))
+
+	queries := db.Queries()
+	assert.Equal(t, "ROLLBACK", queries[len(queries)-1])
+}
+
+func Test_Relay_StartStop(t *testing.T) {
+	t.Run("Stop before start", func(t *testing.T) {
+		db := newFakeDB()
+		relay := outbox.NewRelay(newFakeGetter(t, db), &fakePublisher{}, outbox.WithPollInterval(time.Millisecond))
+		relay.Stop()
+
+		relay.Start()
+		time.Sleep(20 * time.Millisecond)
+		assert.Empty(t, db.Queries(), "a stopped relay isn't started")
+	})
+
+	t.Run("Twice", func(t *testing.T) {
+		relay := outbox.NewRelay(newFakeGetter(t, newFakeDB()), &fakePublisher{}, outbox.WithPollInterval(time.Millisecond))
+		relay.Start()
+		relay.Start()
+
+		relay.Stop()
+		relay.Stop()
+	})
+}
@@ -38,6 +38,18 @@ type DBConnPool struct {
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
 }
 
+type ReplicaHealthCheck struct {
+	// Interval is the interval between probes.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// Timeout is the timeout of a single probe.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// MaxLag is the maximum replication lag of a healthy instance,
+	// measured using pg_last_xact_replay_timestamp. Zero disables the lag check.
+	MaxLag time.Duration `mapstructure:"max_lag"`
+}
+
 // Config represents the configuration for a database connection.
 type Config struct {
 	// URI is the URI of the read-write database instance to connect to.
@@ -47,14 +59,41 @@ type Config struct {
 	// This is optional and can be set to nil if read-write splitting is not required.
 	ReadonlyURL *string `mapstructure:"readonly_url"`
 
+	// ReadonlyURLs is the list of URLs of the read-only database instances, used along with ReadonlyURL.
+	// This is optional and can be empty if read-write splitting is not required.
+	ReadonlyURLs []string `mapstructure:"readonly_urls"`
+
+	// ReplicaPolicy is the policy of choosing the read-only database instance for a query.
+	// Possible values are "random", "round_robin" and "least_connections".
+	// This is optional and the default value is "random".
+	ReplicaPolicy ReplicaPolicy `mapstructure:"replica_policy"`
+
+	// ReplicaHealthCheck is the health probing settings for the read-only database instances.
+	// Unhealthy instances are excluded from the query routing until they recover.
+	// This is optional and can be set to nil if probing is not required.
+	ReplicaHealthCheck *ReplicaHealthCheck `mapstructure:"replica_health_check"`
+
 	// LogLevel is the logging level for the database connection.
 	// Possible values are "silent", "error", "warn", and "info".
 	// This is optional and the default value is "error".
 	LogLevel LogLevel `mapstructure:"log_level"`
 
+	// SlowThreshold is the query duration above which the query is logged as slow on the "warn" level.
+	// This is optional and the default value is 1 second.
+	SlowThreshold time.Duration `mapstructure:"slow_threshold"`
+
+	// RedactParams replaces the query parameter values with placeholders in the logged queries.
+	// This is optional and the default value is false.
+	RedactParams bool `mapstructure:"redact_params"`
+
 	// ConnPool is the connection pool settings for the database connection.
 	// This is optional and can be set to nil if the default connection pool settings are sufficient.
 	ConnPool *DBConnPool `mapstructure:"conn_pool"`
+
+	// ReadYourWritesWindow is the time during which queries are routed to the read-write database instance
+	// after a write made with the context returned by WithReadYourWrites.
+	// This is optional and the default value is 5 seconds.
+	ReadYourWritesWindow time.Duration `mapstructure:"read_your_writes_window"`
 }
 
 var (
@@ -62,12 +101,38 @@ var (
 	defaultMaxOpenConns    = 0
 	defaultConnMaxIdleTime = time.Duration(0)
 	defaultConnMaxLifetime = time.Duration(0)
+
+	defaultSlowThreshold = time.Second
+
+	defaultReadYourWritesWindow = 5 * time.Second
+
+	defaultReplicaHealthCheckInterval = 5 * time.Second
+	defaultReplicaHealthCheckTimeout  = time.Second
 )
 
 func (cfg *Config) applyDefaultValue() {
 	if cfg.LogLevel == "" {
 		cfg.LogLevel = LogLevelError
 	}
+	if cfg.SlowThreshold == 0 {
+		cfg.SlowThreshold = defaultSlowThreshold
+	}
+	if cfg.ReplicaPolicy == "" {
+		cfg.ReplicaPolicy = ReplicaPolicyRandom
+	}
+	if cfg.ReplicaHealthCheck != nil {
+		healthCheck := *cfg.ReplicaHealthCheck
+		if healthCheck.Interval == 0 {
+			healthCheck.Interval = defaultReplicaHealthCheckInterval
+		}
+		if healthCheck.Timeout == 0 {
+			healthCheck.Timeout = defaultReplicaHealthCheckTimeout
+		}
+		cfg.ReplicaHealthCheck = &healthCheck
+	}
+	if cfg.ReadYourWritesWindow == 0 {
+		cfg.ReadYourWritesWindow = defaultReadYourWritesWindow
+	}
 	if cfg.ConnPool == nil {
 		// match the default configuration in database/sql
 		// https: //github.com/golang/go/blob/198074abd7ec36ee71198a109d98f1ccdb7c5533/src/database/sql/sql.go#L912
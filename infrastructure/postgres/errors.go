@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	"github.com/kodenkai-labs/go-lib/errlib"
+)
+
+// SQLSTATE codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	sqlStateNotNullViolation     = "23502"
+	sqlStateForeignKeyViolation  = "23503"
+	sqlStateUniqueViolation      = "23505"
+	sqlStateCheckViolation       = "23514"
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+	sqlStateQueryCanceled        = "57014"
+	sqlStateAdminShutdown        = "57P01"
+	sqlStateCrashShutdown        = "57P02"
+	sqlStateCannotConnectNow     = "57P03"
+
+	sqlStateClassConnectionException = "08"
+)
+
+// TranslateError converts the database error to errlib.AppError with the code and slug matching its cause:
+//   - gorm.ErrRecordNotFound: errlib.NotFoundCode, errlib.SlugNotFound;
+//   - unique_violation: errlib.ConflictCode, errlib.SlugAlreadyExists;
+//   - foreign_key_violation: errlib.UnprocessableCode, errlib.SlugReferenceViolation;
+//   - check_violation: errlib.InvalidInputCode, errlib.SlugConstraintViolation;
+//   - not_null_violation: errlib.InvalidInputCode, errlib.SlugRequiredFieldMissing;
+//   - serialization_failure and deadlock_detected: errlib.ConflictCode, errlib.SlugConcurrentUpdate;
+//   - query_canceled and context cancellation: errlib.InternalCode, errlib.SlugRequestCanceled;
+//   - connection errors: errlib.InternalCode, errlib.SlugDatabaseUnavailable.
+//
+// The name of the violated constraint (or the column for not_null_violation) is set as the error detail,
+// see errlib.Detail. Other errors, including nil, are returned unchanged.
+func TranslateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr errlib.AppError
+	if errors.As(err, &appErr) {
+		return err
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errlib.NewAppError(err, errlib.NotFoundCode, errlib.SlugNotFound)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return translatePgError(err, pgErr)
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return errlib.NewAppError(err, errlib.InternalCode, errlib.SlugRequestCanceled)
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) || errors.Is(err, driver.ErrBadConn) || pgconn.Timeout(err) {
+		return errlib.NewAppError(err, errlib.InternalCode, errlib.SlugDatabaseUnavailable)
+	}
+
+	return err
+}
+
+func translatePgError(err error, pgErr *pgconn.PgError) error {
+	switch pgErr.Code {
+	case sqlStateUniqueViolation:
+		return errlib.NewAppErrorWithDetail(err, errlib.ConflictCode, errlib.SlugAlreadyExists, pgErr.ConstraintName)
+	case sqlStateForeignKeyViolation:
+		return errlib.NewAppErrorWithDetail(
+			err, errlib.UnprocessableCode, errlib.SlugReferenceViolation, pgErr.ConstraintName)
+	case sqlStateCheckViolation:
+		return errlib.NewAppErrorWithDetail(
+			err, errlib.InvalidInputCode, errlib.SlugConstraintViolation, pgErr.ConstraintName)
+	case sqlStateNotNullViolation:
+		return errlib.NewAppErrorWithDetail(err, errlib.InvalidInputCode, errlib.SlugRequiredFieldMissing, pgErr.ColumnName)
+	case sqlStateSerializationFailure, sqlStateDeadlockDetected:
+		return errlib.NewAppError(err, errlib.ConflictCode, errlib.SlugConcurrentUpdate)
+	case sqlStateQueryCanceled:
+		return errlib.NewAppError(err, errlib.InternalCode, errlib.SlugRequestCanceled)
+	case sqlStateAdminShutdown, sqlStateCrashShutdown, sqlStateCannotConnectNow:
+		return errlib.NewAppError(err, errlib.InternalCode, errlib.SlugDatabaseUnavailable)
+	}
+
+	if strings.HasPrefix(pgErr.Code, sqlStateClassConnectionException) {
+		return errlib.NewAppError(err, errlib.InternalCode, errlib.SlugDatabaseUnavailable)
+	}
+
+	return err
+}
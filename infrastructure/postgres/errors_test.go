@@ -0,0 +1,108 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/kodenkai-labs/go-lib/errlib"
+	"github.com/kodenkai-labs/go-lib/infrastructure/postgres"
+)
+
+func Test_TranslateError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   errlib.Code
+		wantSlug   errlib.Slug
+		wantDetail string
+	}{
+		{
+			name:     "Record not found",
+			err:      fmt.Errorf("get user: %w", gorm.ErrRecordNotFound),
+			wantCode: errlib.NotFoundCode,
+			wantSlug: errlib.SlugNotFound,
+		},
+		{
+			name:       "Unique violation",
+			err:        &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"},
+			wantCode:   errlib.ConflictCode,
+			wantSlug:   errlib.SlugAlreadyExists,
+			wantDetail: "users_email_key",
+		},
+		{
+			name:       "Foreign key violation",
+			err:        &pgconn.PgError{Code: "23503", ConstraintName: "orders_user_id_fkey"},
+			wantCode:   errlib.UnprocessableCode,
+			wantSlug:   errlib.SlugReferenceViolation,
+			wantDetail: "orders_user_id_fkey",
+		},
+		{
+			name:       "Check violation",
+			err:        &pgconn.PgError{Code: "23514", ConstraintName: "orders_amount_check"},
+			wantCode:   errlib.InvalidInputCode,
+			wantSlug:   errlib.SlugConstraintViolation,
+			wantDetail: "orders_amount_check",
+		},
+		{
+			name:       "Not null violation",
+			err:        &pgconn.PgError{Code: "23502", ColumnName: "email"},
+			wantCode:   errlib.InvalidInputCode,
+			wantSlug:   errlib.SlugRequiredFieldMissing,
+			wantDetail: "email",
+		},
+		{
+			name:     "Serialization failure",
+			err:      &pgconn.PgError{Code: "40001"},
+			wantCode: errlib.ConflictCode,
+			wantSlug: errlib.SlugConcurrentUpdate,
+		},
+		{
+			name:     "Query canceled",
+			err:      &pgconn.PgError{Code: "57014"},
+			wantCode: errlib.InternalCode,
+			wantSlug: errlib.SlugRequestCanceled,
+		},
+		{
+			name:     "Context deadline exceeded",
+			err:      fmt.Errorf("query: %w", context.DeadlineExceeded),
+			wantCode: errlib.InternalCode,
+			wantSlug: errlib.SlugRequestCanceled,
+		},
+		{
+			name:     "Connection exception",
+			err:      &pgconn.PgError{Code: "08006"},
+			wantCode: errlib.InternalCode,
+			wantSlug: errlib.SlugDatabaseUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := postgres.TranslateError(tt.err)
+
+			var appErr errlib.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, tt.wantCode, appErr.Code())
+			assert.Equal(t, tt.wantSlug, appErr.Slug())
+			assert.ErrorIs(t, err, tt.err, "the cause must be kept")
+			assert.Equal(t, tt.wantDetail, errlib.Detail(err))
+		})
+	}
+}
+
+func Test_TranslateError_Unchanged(t *testing.T) {
+	assert.NoError(t, postgres.TranslateError(nil))
+
+	err := errors.New("some error")
+	assert.Equal(t, err, postgres.TranslateError(err))
+
+	pgErr := &pgconn.PgError{Code: "42P01"}
+	assert.Equal(t, pgErr, postgres.TranslateError(pgErr))
+}
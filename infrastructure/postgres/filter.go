@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kodenkai-labs/go-lib/filter"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// FilterScope applies the filter conditions and sorting to the query.
+func FilterScope(f filter.Filter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, condition := range f.Conditions {
+			db = db.Where(conditionExpression(condition))
+		}
+
+		return db.Scopes(SortScope(f.Sort))
+	}
+}
+
+func conditionExpression(condition filter.Condition) clause.Expression {
+	column := clause.Column{Name: condition.Column}
+
+	switch condition.Operator {
+	case filter.OperatorNe:
+		return clause.Neq{Column: column, Value: condition.Value}
+	case filter.OperatorGt:
+		return clause.Gt{Column: column, Value: condition.Value}
+	case filter.OperatorGte:
+		return clause.Gte{Column: column, Value: condition.Value}
+	case filter.OperatorLt:
+		return clause.Lt{Column: column, Value: condition.Value}
+	case filter.OperatorLte:
+		return clause.Lte{Column: column, Value: condition.Value}
+	case filter.OperatorIn:
+		values, _ := condition.Value.([]any)
+		return clause.IN{Column: column, Values: values}
+	case filter.OperatorNin:
+		values, _ := condition.Value.([]any)
+		return clause.Not(clause.IN{Column: column, Values: values})
+	case filter.OperatorContains:
+		value, _ := condition.Value.(string)
+		return clause.Expr{SQL: "? ILIKE ?", Vars: []any{column, "%" + likeEscaper.Replace(value) + "%"}}
+	default:
+		return clause.Eq{Column: column, Value: condition.Value}
+	}
+}
@@ -0,0 +1,30 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kodenkai-labs/go-lib/filter"
+	"github.com/kodenkai-labs/go-lib/infrastructure/postgres"
+	"github.com/kodenkai-labs/go-lib/pagination"
+)
+
+func Test_FilterScope(t *testing.T) {
+	f := filter.Filter{
+		Conditions: []filter.Condition{
+			{Column: "id", Operator: filter.OperatorIn, Value: []any{int64(1), int64(2)}},
+			{Column: "name", Operator: filter.OperatorContains, Value: "50%_off"},
+			{Column: "id", Operator: filter.OperatorNin, Value: []any{int64(3)}},
+		},
+		Sort: []pagination.SortField{{Field: "name", Desc: true}},
+	}
+
+	var items []item
+	stmt := newDryRunDB(t).Scopes(postgres.FilterScope(f)).Find(&items).Statement
+
+	assert.Equal(t,
+		`SELECT * FROM "items" WHERE "id" IN ($1,$2) AND "name" ILIKE $3 AND "id" <> $4 ORDER BY "name" DESC`,
+		stmt.SQL.String())
+	assert.Equal(t, []any{int64(1), int64(2), `%50\%\_off%`, int64(3)}, stmt.Vars)
+}
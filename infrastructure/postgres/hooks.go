@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// trxState is the transaction state stored in the context by DBGetter.
+type trxState struct {
+	db    *gorm.DB
+	hooks *trxHooks
+}
+
+func trxStateFrom(ctx context.Context) (*trxState, bool) {
+	state, ok := ctx.Value(trxKey).(*trxState)
+	return state, ok
+}
+
+// trxHooks holds the hooks registered in a transaction or in a savepoint.
+type trxHooks struct {
+	mu sync.Mutex
+
+	// seq numbers the hooks in the order of registration, it's shared by the savepoints of the transaction.
+	seq     *atomic.Uint64
+	entries []hookEntry
+}
+
+type hookKind int
+
+const (
+	hookAfterCommit hookKind = iota
+	hookAfterRollback
+)
+
+// hookEntry is a registered hook.
+type hookEntry struct {
+	seq  uint64
+	kind hookKind
+	fn   func(ctx context.Context)
+
+	// rolledBack is set for the AfterRollback hooks of the rolled back savepoints,
+	// which run regardless of the outcome of the outermost transaction.
+	rolledBack bool
+}
+
+func newTrxHooks(parent *trxHooks) *trxHooks {
+	if parent != nil {
+		return &trxHooks{seq: parent.seq}
+	}
+	return &trxHooks{seq: new(atomic.Uint64)}
+}
+
+func (h *trxHooks) add(kind hookKind, fn func(ctx context.Context)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entries = append(h.entries, hookEntry{seq: h.seq.Add(1), kind: kind, fn: fn})
+}
+
+// AfterCommit registers fn to be called after the outermost transaction carried by the context is committed.
+// Hooks are called in the order of registration. If fn is registered inside a savepoint which is rolled back,
+// fn is discarded. If the context doesn't carry a transaction, fn is called immediately.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	state, ok := trxStateFrom(ctx)
+	if !ok {
+		fn(ctx)
+		return
+	}
+
+	state.hooks.add(hookAfterCommit, fn)
+}
+
+// AfterRollback registers fn to be called after the outermost transaction carried by the context finishes,
+// if the changes made after the registration are rolled back: either the whole transaction
+// or the savepoint fn is registered in. Hooks are called in the order of registration.
+// If the context doesn't carry a transaction, fn is never called.
+func AfterRollback(ctx context.Context, fn func(ctx context.Context)) {
+	state, ok := trxStateFrom(ctx)
+	if !ok {
+		return
+	}
+
+	state.hooks.add(hookAfterRollback, fn)
+}
+
+// merge moves the hooks of a finished savepoint to the hooks of its parent.
+// The AfterCommit hooks of a rolled back savepoint are discarded.
+func (h *trxHooks) merge(child *trxHooks, committed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, entry := range child.entries {
+		if !committed {
+			if entry.kind == hookAfterCommit {
+				continue
+			}
+			entry.rolledBack = true
+		}
+		h.entries = append(h.entries, entry)
+	}
+}
+
+// run calls the hooks of the finished outermost transaction in the order of registration.
+func (h *trxHooks) run(ctx context.Context, committed bool) {
+	h.mu.Lock()
+	var hooks []hookEntry
+	for _, entry := range h.entries {
+		if entry.rolledBack || (entry.kind == hookAfterCommit) == committed {
+			hooks = append(hooks, entry)
+		}
+	}
+	h.mu.Unlock()
+
+	slices.SortFunc(hooks, func(a, b hookEntry) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+
+	for _, entry := range hooks {
+		entry.fn(ctx)
+	}
+}
@@ -0,0 +1,124 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/postgres"
+	"github.com/kodenkai-labs/go-lib/internal/sqltest"
+)
+
+func Test_TransactionHooks(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name string
+		// fc runs in the outermost transaction, hook returns a hook recording its name.
+		fc   func(ctx context.Context, getter *postgres.DBGetter, hook func(name string) func(context.Context)) error
+		want []string
+	}{
+		{
+			name: "Commit",
+			fc: func(ctx context.Context, _ *postgres.DBGetter, hook func(string) func(context.Context)) error {
+				postgres.AfterCommit(ctx, hook("commit 1"))
+				postgres.AfterRollback(ctx, hook("rollback"))
+				postgres.AfterCommit(ctx, hook("commit 2"))
+				return nil
+			},
+			want: []string{"commit 1", "commit 2"},
+		},
+		{
+			name: "Outer rollback",
+			fc: func(ctx context.Context, _ *postgres.DBGetter, hook func(string) func(context.Context)) error {
+				postgres.AfterRollback(ctx, hook("rollback 1"))
+				postgres.AfterCommit(ctx, hook("commit"))
+				postgres.AfterRollback(ctx, hook("rollback 2"))
+				return errFailed
+			},
+			want: []string{"rollback 1", "rollback 2"},
+		},
+		{
+			name: "Savepoint rollback",
+			fc: func(ctx context.Context, getter *postgres.DBGetter, hook func(string) func(context.Context)) error {
+				postgres.AfterRollback(ctx, hook("outer rollback"))
+				_ = getter.Transaction(ctx, func(ctx context.Context) error {
+					postgres.AfterCommit(ctx, hook("savepoint commit"))
+					postgres.AfterRollback(ctx, hook("savepoint rollback"))
+					return errFailed
+				})
+				postgres.AfterCommit(ctx, hook("outer commit"))
+				return nil
+			},
+			want: []string{"savepoint rollback", "outer commit"},
+		},
+		{
+			name: "Savepoint rollback followed by outer rollback",
+			fc: func(ctx context.Context, getter *postgres.DBGetter, hook func(string) func(context.Context)) error {
+				postgres.AfterRollback(ctx, hook("outer rollback 1"))
+				_ = getter.Transaction(ctx, func(ctx context.Context) error {
+					postgres.AfterRollback(ctx, hook("savepoint rollback"))
+					return errFailed
+				})
+				postgres.AfterRollback(ctx, hook("outer rollback 2"))
+				return errFailed
+			},
+			want: []string{"outer rollback 1", "savepoint rollback", "outer rollback 2"},
+		},
+		{
+			name: "Committed savepoint followed by outer rollback",
+			fc: func(ctx context.Context, getter *postgres.DBGetter, hook func(string) func(context.Context)) error {
+				postgres.AfterRollback(ctx, hook("outer rollback"))
+				_ = getter.Transaction(ctx, func(ctx context.Context) error {
+					postgres.AfterCommit(ctx, hook("savepoint commit"))
+					postgres.AfterRollback(ctx, hook("savepoint rollback"))
+					return nil
+				})
+				return errFailed
+			},
+			want: []string{"outer rollback", "savepoint rollback"},
+		},
+		{
+			name: "Committed savepoint",
+			fc: func(ctx context.Context, getter *postgres.DBGetter, hook func(string) func(context.Context)) error {
+				postgres.AfterCommit(ctx, hook("outer commit 1"))
+				_ = getter.Transaction(ctx, func(ctx context.Context) error {
+					postgres.AfterCommit(ctx, hook("savepoint commit"))
+					return nil
+				})
+				postgres.AfterCommit(ctx, hook("outer commit 2"))
+				return nil
+			},
+			want: []string{"outer commit 1", "savepoint commit", "outer commit 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := postgres.NewDBGetterFromGormInstance((&sqltest.DB{}).OpenGorm(t))
+
+			var called []string
+			hook := func(name string) func(context.Context) {
+				return func(context.Context) {
+					called = append(called, name)
+				}
+			}
+
+			_ = getter.Transaction(context.Background(), func(ctx context.Context) error {
+				return tt.fc(ctx, getter, hook)
+			})
+
+			assert.Equal(t, tt.want, called)
+		})
+	}
+}
+
+func Test_TransactionHooks_NoTransaction(t *testing.T) {
+	var called []string
+	postgres.AfterCommit(context.Background(), func(context.Context) { called = append(called, "commit") })
+	postgres.AfterRollback(context.Background(), func(context.Context) { called = append(called, "rollback") })
+
+	assert.Equal(t, []string{"commit"}, called)
+}
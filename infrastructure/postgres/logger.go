@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// WithLogFields sets a function extracting fields, e.g. request or trace IDs, from the query context
+// to be added to every record logged for the query.
+func WithLogFields(fn func(ctx context.Context) logrus.Fields) Option {
+	return func(getter *DBGetter) {
+		getter.logFields = fn
+	}
+}
+
+// logrusLogger is a gorm logger emitting structured records through logrus.
+type logrusLogger struct {
+	level         gormLogger.LogLevel
+	slowThreshold time.Duration
+	redactParams  bool
+	fields        func(ctx context.Context) logrus.Fields
+}
+
+func (l *logrusLogger) LogMode(level gormLogger.LogLevel) gormLogger.Interface {
+	newLogger := *l
+	newLogger.level = level
+	return &newLogger
+}
+
+func (l *logrusLogger) Info(ctx context.Context, msg string, data ...any) {
+	if l.level >= gormLogger.Info {
+		l.entry(ctx).Infof(msg, data...)
+	}
+}
+
+func (l *logrusLogger) Warn(ctx context.Context, msg string, data ...any) {
+	if l.level >= gormLogger.Warn {
+		l.entry(ctx).Warnf(msg, data...)
+	}
+}
+
+func (l *logrusLogger) Error(ctx context.Context, msg string, data ...any) {
+	if l.level >= gormLogger.Error {
+		l.entry(ctx).Errorf(msg, data...)
+	}
+}
+
+func (l *logrusLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormLogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	isSlow := l.slowThreshold != 0 && elapsed > l.slowThreshold
+
+	switch {
+	case err != nil && l.level >= gormLogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		l.queryEntry(ctx, elapsed, fc).WithError(err).Error("sql query failed")
+	case isSlow && l.level >= gormLogger.Warn:
+		l.queryEntry(ctx, elapsed, fc).WithField("slow_threshold", l.slowThreshold).Warn("slow sql query")
+	case l.level >= gormLogger.Info:
+		l.queryEntry(ctx, elapsed, fc).Info("sql query")
+	}
+}
+
+// ParamsFilter implements gorm.ParamsFilter, so the logged queries contain placeholders
+// instead of the parameter values when the redaction is enabled.
+func (l *logrusLogger) ParamsFilter(_ context.Context, sql string, params ...any) (string, []any) {
+	if l.redactParams {
+		return sql, nil
+	}
+	return sql, params
+}
+
+func (l *logrusLogger) entry(ctx context.Context) *logrus.Entry {
+	entry := logrus.WithContext(ctx)
+	if l.fields != nil && ctx != nil {
+		entry = entry.WithFields(l.fields(ctx))
+	}
+	return entry
+}
+
+func (l *logrusLogger) queryEntry(
+	ctx context.Context, elapsed time.Duration, fc func() (sql string, rowsAffected int64),
+) *logrus.Entry {
+	sql, rows := fc()
+
+	return l.entry(ctx).WithFields(logrus.Fields{
+		"sql":     sql,
+		"rows":    rows,
+		"elapsed": elapsed,
+		"source":  utils.FileWithLineNum(),
+	})
+}
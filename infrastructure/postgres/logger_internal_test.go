@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gormpostgres "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+
+	"github.com/kodenkai-labs/go-lib/internal/sqltest"
+)
+
+type requestIDKey struct{}
+
+// newLogHook records the entries of the standard logger until the test ends.
+func newLogHook(t *testing.T) *test.Hook {
+	t.Helper()
+
+	logger := logrus.StandardLogger()
+	out := logger.Out
+	logger.SetOutput(io.Discard)
+
+	hook := test.NewGlobal()
+	t.Cleanup(func() {
+		logger.SetOutput(out)
+		logger.ReplaceHooks(make(logrus.LevelHooks))
+	})
+
+	return hook
+}
+
+// openLogged opens a gorm database on the fake logging the queries with the logger.
+func openLogged(t *testing.T, fake *sqltest.DB, logger *logrusLogger) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(gormpostgres.New(gormpostgres.Config{Conn: fake.Open()}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 logger,
+	})
+	require.NoError(t, err)
+
+	return db
+}
+
+func Test_logrusLogger_ParamsFilter(t *testing.T) {
+	tests := []struct {
+		name         string
+		redactParams bool
+		wantSecret   bool
+	}{
+		{
+			name:       "Params",
+			wantSecret: true,
+		},
+		{
+			name:         "Redacted params",
+			redactParams: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := newLogHook(t)
+			db := openLogged(t, &sqltest.DB{}, &logrusLogger{level: gormLogger.Info, redactParams: tt.redactParams})
+
+			require.NoError(t, db.Exec("UPDATE users SET password = ?", "secret").Error)
+
+			entry := hook.LastEntry()
+			require.NotNil(t, entry)
+			assert.Equal(t, "sql query", entry.Message)
+			sql, _ := entry.Data["sql"].(string)
+			assert.Equal(t, tt.wantSecret, strings.Contains(sql, "'secret'"), sql)
+		})
+	}
+}
+
+func Test_logrusLogger_Trace(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name        string
+		level       gormLogger.LogLevel
+		elapsed     time.Duration
+		err         error
+		wantLevel   logrus.Level
+		wantMessage string
+	}{
+		{
+			name:        "Failed query",
+			level:       gormLogger.Error,
+			err:         errFailed,
+			wantLevel:   logrus.ErrorLevel,
+			wantMessage: "sql query failed",
+		},
+		{
+			name:  "Record not found is ignored",
+			level: gormLogger.Error,
+			err:   gorm.ErrRecordNotFound,
+		},
+		{
+			name:        "Record not found is logged as a query",
+			level:       gormLogger.Info,
+			err:         gorm.ErrRecordNotFound,
+			wantLevel:   logrus.InfoLevel,
+			wantMessage: "sql query",
+		},
+		{
+			name:        "Slow query",
+			level:       gormLogger.Warn,
+			elapsed:     2 * time.Second,
+			wantLevel:   logrus.WarnLevel,
+			wantMessage: "slow sql query",
+		},
+		{
+			name:    "Fast query below info level",
+			level:   gormLogger.Warn,
+			elapsed: time.Millisecond,
+		},
+		{
+			name:    "Silent",
+			level:   gormLogger.Silent,
+			elapsed: 2 * time.Second,
+			err:     errFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := newLogHook(t)
+			logger := &logrusLogger{level: tt.level, slowThreshold: time.Second}
+
+			logger.Trace(context.Background(), time.Now().Add(-tt.elapsed), func() (string, int64) {
+				return "SELECT 1", 1
+			}, tt.err)
+
+			if tt.wantMessage == "" {
+				assert.Empty(t, hook.AllEntries())
+				return
+			}
+
+			require.Len(t, hook.AllEntries(), 1)
+			entry := hook.LastEntry()
+			assert.Equal(t, tt.wantLevel, entry.Level)
+			assert.Equal(t, tt.wantMessage, entry.Message)
+			assert.Equal(t, "SELECT 1", entry.Data["sql"])
+			assert.Equal(t, int64(1), entry.Data["rows"])
+		})
+	}
+}
+
+func Test_logrusLogger_RecordNotFound(t *testing.T) {
+	hook := newLogHook(t)
+	fake := &sqltest.DB{
+		Query: func(int, string, []any) (driver.Rows, error) {
+			return sqltest.NewRows([]string{"id"}), nil
+		},
+	}
+	db := openLogged(t, fake, &logrusLogger{level: gormLogger.Error})
+
+	var user struct{ ID int64 }
+	err := db.Table("users").First(&user).Error
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.Empty(t, hook.AllEntries())
+}
+
+func Test_WithLogFields(t *testing.T) {
+	hook := newLogHook(t)
+
+	getter := &DBGetter{}
+	WithLogFields(func(ctx context.Context) logrus.Fields {
+		return logrus.Fields{"request_id": ctx.Value(requestIDKey{})}
+	})(getter)
+
+	db := openLogged(t, &sqltest.DB{}, &logrusLogger{level: gormLogger.Info, fields: getter.logFields})
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "request-1")
+
+	require.NoError(t, db.WithContext(ctx).Exec("UPDATE users SET name = 'name'").Error)
+
+	entry := hook.LastEntry()
+	require.NotNil(t, entry)
+	assert.Equal(t, "request-1", entry.Data["request_id"])
+	assert.Equal(t, "UPDATE users SET name = 'name'", entry.Data["sql"])
+}
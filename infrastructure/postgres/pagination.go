@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kodenkai-labs/go-lib/pagination"
+)
+
+// SortScope orders the query by the sort fields.
+// The fields are used as column names, so they must be validated, e.g. by pagination.ParseSort.
+func SortScope(sort []pagination.SortField) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, field := range sort {
+			db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: field.Field}, Desc: field.Desc})
+		}
+		return db
+	}
+}
+
+// KeysetScope selects the page of the keyset pagination request.
+// It fetches one extra row to detect whether there are more rows, as expected by pagination.NewKeysetPage.
+func KeysetScope(req pagination.Keyset) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(req.After) > 0 {
+			db = db.Where(keysetCondition(req))
+		}
+
+		// a backward page is fetched in the reversed order and reversed back by pagination.NewKeysetPage
+		for _, field := range req.Sort {
+			db = db.Order(clause.OrderByColumn{
+				Column: clause.Column{Name: field.Field},
+				Desc:   field.Desc != req.Backward,
+			})
+		}
+
+		return db.Limit(req.Limit + 1)
+	}
+}
+
+// keysetCondition builds the condition selecting the rows following req.After in the order of the page:
+// (a > x) OR (a = x AND b > y) OR ...
+func keysetCondition(req pagination.Keyset) clause.Expression {
+	alternatives := make([]clause.Expression, 0, len(req.Sort))
+	for i, field := range req.Sort {
+		conditions := make([]clause.Expression, 0, i+1)
+		for j := range i {
+			conditions = append(conditions, clause.Eq{Column: clause.Column{Name: req.Sort[j].Field}, Value: req.After[j]})
+		}
+
+		column := clause.Column{Name: field.Field}
+		if field.Desc != req.Backward {
+			conditions = append(conditions, clause.Lt{Column: column, Value: req.After[i]})
+		} else {
+			conditions = append(conditions, clause.Gt{Column: column, Value: req.After[i]})
+		}
+
+		alternatives = append(alternatives, clause.And(conditions...))
+	}
+
+	return clause.Or(alternatives...)
+}
+
+// OffsetScope selects the page of the offset pagination request.
+func OffsetScope(req pagination.Offset) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(SortScope(req.Sort)).Limit(req.Limit).Offset(req.Offset)
+	}
+}
+
+// PaginateOffset counts the rows selected by the query and fetches the page of the offset pagination request.
+func PaginateOffset[T any](db *gorm.DB, req pagination.Offset) (pagination.OffsetPage[T], error) {
+	page := pagination.OffsetPage[T]{Limit: req.Limit, Offset: req.Offset}
+
+	if err := db.Session(&gorm.Session{}).Model(new(T)).Count(&page.Total).Error; err != nil {
+		return page, fmt.Errorf("count rows: %w", err)
+	}
+
+	if err := db.Session(&gorm.Session{}).Scopes(OffsetScope(req)).Find(&page.Items).Error; err != nil {
+		return page, fmt.Errorf("find rows: %w", err)
+	}
+
+	return page, nil
+}
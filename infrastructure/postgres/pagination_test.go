@@ -0,0 +1,68 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pgdriver "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/postgres"
+	"github.com/kodenkai-labs/go-lib/pagination"
+)
+
+type item struct {
+	ID   int
+	Name string
+}
+
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(pgdriver.New(pgdriver.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	require.NoError(t, err)
+
+	return db
+}
+
+func Test_KeysetScope(t *testing.T) {
+	sort := []pagination.SortField{{Field: "name", Desc: true}, {Field: "id"}}
+
+	tests := []struct {
+		name    string
+		req     pagination.Keyset
+		wantSQL string
+	}{
+		{
+			name:    "First page",
+			req:     pagination.Keyset{Limit: 10, Sort: sort},
+			wantSQL: `SELECT * FROM "items" ORDER BY "name" DESC,"id" LIMIT $1`,
+		},
+		{
+			name: "Next page",
+			req:  pagination.Keyset{Limit: 10, Sort: sort, After: []any{"b", 2}},
+			wantSQL: `SELECT * FROM "items" WHERE ("name" < $1 OR ("name" = $2 AND "id" > $3)) ` +
+				`ORDER BY "name" DESC,"id" LIMIT $4`,
+		},
+		{
+			name: "Previous page",
+			req:  pagination.Keyset{Limit: 10, Sort: sort, After: []any{"b", 2}, Backward: true},
+			wantSQL: `SELECT * FROM "items" WHERE ("name" > $1 OR ("name" = $2 AND "id" < $3)) ` +
+				`ORDER BY "name","id" DESC LIMIT $4`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var items []item
+			stmt := newDryRunDB(t).Scopes(postgres.KeysetScope(tt.req)).Find(&items).Statement
+
+			assert.Equal(t, tt.wantSQL, stmt.SQL.String())
+			assert.Equal(t, tt.req.Limit+1, stmt.Vars[len(stmt.Vars)-1])
+		})
+	}
+}
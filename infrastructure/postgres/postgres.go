@@ -2,10 +2,10 @@ package postgres
 
 import (
 	"context"
-	"log"
-	"os"
+	"errors"
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	gormLogger "gorm.io/gorm/logger"
@@ -28,9 +28,18 @@ var trxKey = &transactionKey{}
 
 // DBGetter implements the DBContextGetter interface, allowing retrieval of a read/write database connection.
 type DBGetter struct {
-	db *gorm.DB
+	db       *gorm.DB
+	replicas *replicaSet
+
+	stickyWindow time.Duration
+	stickyStore  StickyStore
+
+	logFields func(ctx context.Context) logrus.Fields
 }
 
+// Option represents a function that configures a DBGetter.
+type Option func(getter *DBGetter)
+
 // NewDBGetter creates a new DBGetter instance with the specified database configuration.
 // If you are using a read-write splitting database connection, the `dbresolver` will automatically select
 // the appropriate connection based on the SQL to be executed.
@@ -45,8 +54,8 @@ type DBGetter struct {
 // For more information, read https://gorm.io/docs/dbresolver.html#Read-x2F-Write-Splitting
 // *Note* that when using read-write splitting, there is a potential issue where a read operation immediately
 // following a write operation may not see the updated data if it is executed on a different read-only replica
-// that has not yet been updated with the new data.
-func NewDBGetter(cfg Config) (*DBGetter, error) {
+// that has not yet been updated with the new data. To avoid it, use WithPrimary or WithReadYourWrites.
+func NewDBGetter(cfg Config, opts ...Option) (*DBGetter, error) {
 	cfg.applyDefaultValue()
 
 	logLevel, err := newLogLevelFromString(cfg.LogLevel)
@@ -54,52 +63,102 @@ func NewDBGetter(cfg Config) (*DBGetter, error) {
 		return nil, err
 	}
 
+	if err = validateReplicaPolicy(cfg.ReplicaPolicy); err != nil {
+		return nil, err
+	}
+
+	getter := &DBGetter{stickyWindow: cfg.ReadYourWritesWindow}
+	for _, opt := range opts {
+		opt(getter)
+	}
+
 	db, err := gorm.Open(postgres.Open(cfg.URI), &gorm.Config{
 		SkipDefaultTransaction: true,
-		Logger: gormLogger.New(
-			log.New(os.Stdout, "\r\n", log.LstdFlags),
-			gormLogger.Config{
-				SlowThreshold:             time.Second,
-				LogLevel:                  logLevel,
-				IgnoreRecordNotFoundError: true,
-				Colorful:                  true,
-			}),
+		Logger: &logrusLogger{
+			level:         logLevel,
+			slowThreshold: cfg.SlowThreshold,
+			redactParams:  cfg.RedactParams,
+			fields:        getter.logFields,
+		},
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	var replicas []gorm.Dialector
-	if cfg.ReadonlyURL != nil {
-		replicas = append(replicas, postgres.Open(*cfg.ReadonlyURL))
+	sourceDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	replicas, err := newReplicaSet(cfg, sourceDB)
+	if err != nil {
+		return nil, err
 	}
 
 	resolver := dbresolver.Register(
 		dbresolver.Config{
-			Sources:           []gorm.Dialector{postgres.Open(cfg.URI)},
-			Replicas:          replicas,
+			Sources:           []gorm.Dialector{postgres.New(postgres.Config{Conn: sourceDB})},
+			Replicas:          replicas.dialectors(),
+			Policy:            replicas,
 			TraceResolverMode: logLevel == gormLogger.Info,
 		}).SetConnMaxIdleTime(cfg.ConnPool.ConnMaxIdleTime).
 		SetConnMaxLifetime(cfg.ConnPool.ConnMaxLifetime).
 		SetMaxIdleConns(cfg.ConnPool.MaxIdleConns).
 		SetMaxOpenConns(cfg.ConnPool.MaxOpenConns)
 	if err = db.Use(resolver); err != nil {
-		return nil, err
+		return nil, errors.Join(err, replicas.close())
 	}
-	return &DBGetter{db: db}, nil
+
+	getter.db = db
+	getter.replicas = replicas
+
+	if err = getter.registerStickyCallbacks(); err != nil {
+		return nil, errors.Join(err, replicas.close())
+	}
+
+	replicas.start()
+	return getter, nil
 }
 
+// NewDBGetterFromGormInstance creates a new DBGetter on top of the existing gorm instance.
+// It registers the callbacks detecting writes for WithReadYourWrites on the instance,
+// logging the error if they can't be registered, see NewDBGetterFromGormInstanceWithOptions.
 func NewDBGetterFromGormInstance(db *gorm.DB) *DBGetter {
-	return &DBGetter{db: db}
+	getter, err := NewDBGetterFromGormInstanceWithOptions(db)
+	if err != nil {
+		logrus.WithError(err).Error("register read-your-writes callbacks")
+		return &DBGetter{db: db, stickyWindow: defaultReadYourWritesWindow}
+	}
+
+	return getter
+}
+
+// NewDBGetterFromGormInstanceWithOptions creates a new DBGetter on top of the existing gorm instance
+// configured by the options. It registers the callbacks detecting writes for WithReadYourWrites on the instance.
+func NewDBGetterFromGormInstanceWithOptions(db *gorm.DB, opts ...Option) (*DBGetter, error) {
+	getter := &DBGetter{
+		db:           db,
+		stickyWindow: defaultReadYourWritesWindow,
+	}
+	for _, opt := range opts {
+		opt(getter)
+	}
+
+	if err := getter.registerStickyCallbacks(); err != nil {
+		return nil, err
+	}
+
+	return getter, nil
 }
 
 func (getter *DBGetter) GetSourceDB() *gorm.DB {
 	return getter.db
 }
 
+// HealthCheck pings the source database.
+// Read-only replicas aren't checked, since queries fall back to the source when they are unavailable,
+// use ReplicaHealth to monitor them.
 func (getter *DBGetter) HealthCheck() error {
-	// gorm dbresolver doesn't support getting replica connection
-	// https://github.com/go-gorm/dbresolver/issues/45
 	sqlDB, err := getter.db.DB()
 	if err != nil {
 		return err
@@ -107,25 +166,42 @@ func (getter *DBGetter) HealthCheck() error {
 	return sqlDB.Ping()
 }
 
+// ReplicaHealth pings the read-only replicas and returns their statuses.
+// Each ping is limited by ReplicaHealthCheck.Timeout, or 1 second if the health check isn't configured.
+func (getter *DBGetter) ReplicaHealth() []ReplicaStatus {
+	if getter.replicas == nil {
+		return nil
+	}
+	return getter.replicas.health()
+}
+
 func (getter *DBGetter) DBFrom(ctx context.Context) *gorm.DB {
-	if db, ok := ctx.Value(trxKey).(*gorm.DB); ok {
-		return db
+	if state, ok := trxStateFrom(ctx); ok {
+		return state.db
 	}
-	return getter.db
+	if state, ok := stickyStateFrom(ctx); ok {
+		return getter.stickyDB(ctx, state)
+	}
+	return getter.db.WithContext(ctx)
 }
 
+// Transaction runs fc in a transaction propagated through the context.
+// When the context already carries a transaction, a savepoint is created in it (see PropagationNested).
+// Use TransactionWithOptions to control propagation, isolation level and read-only mode.
 func (getter *DBGetter) Transaction(ctx context.Context, fc func(ctx context.Context) error) error {
-	return getter.DBFrom(ctx).Transaction(func(tx *gorm.DB) error {
-		return fc(context.WithValue(ctx, trxKey, tx))
-	})
+	return getter.TransactionWithOptions(ctx, fc, WithPropagation(PropagationNested))
 }
 
+// Close stops probing the replicas and closes the source database and all read-only replicas.
 func (getter *DBGetter) Close() error {
-	// gorm dbresolver doesn't support getting replica connection
-	// https://github.com/go-gorm/dbresolver/issues/45
+	var replicasErr error
+	if getter.replicas != nil {
+		replicasErr = getter.replicas.close()
+	}
+
 	sqlDB, err := getter.db.DB()
 	if err != nil {
-		return err
+		return errors.Join(err, replicasErr)
 	}
-	return sqlDB.Close()
+	return errors.Join(sqlDB.Close(), replicasErr)
 }
\ No newline at end of file
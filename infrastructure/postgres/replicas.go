@@ -0,0 +1,257 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type ReplicaPolicy string
+
+const (
+	ReplicaPolicyRandom           ReplicaPolicy = "random"
+	ReplicaPolicyRoundRobin       ReplicaPolicy = "round_robin"
+	ReplicaPolicyLeastConnections ReplicaPolicy = "least_connections"
+)
+
+func validateReplicaPolicy(policy ReplicaPolicy) error {
+	switch policy {
+	case ReplicaPolicyRandom, ReplicaPolicyRoundRobin, ReplicaPolicyLeastConnections:
+		return nil
+	default:
+		return errors.New("invalid replica policy")
+	}
+}
+
+// replicaLagQuery returns the replication lag in seconds.
+// The lag is zero when the replica has replayed everything it received,
+// since pg_last_xact_replay_timestamp doesn't move while there are no writes on the primary.
+const replicaLagQuery = `SELECT CASE
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+type replica struct {
+	index   int
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// replicaSet manages the read-only database instances.
+// It implements dbresolver.Policy: queries are routed to the healthy replicas only,
+// and to the source database if there are none.
+type replicaSet struct {
+	source   *sql.DB
+	replicas []*replica
+	byPool   map[gorm.ConnPool]*replica
+
+	policy      ReplicaPolicy
+	healthCheck *ReplicaHealthCheck
+	counter     atomic.Uint64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newReplicaSet(cfg Config, source *sql.DB) (*replicaSet, error) {
+	urls := cfg.ReadonlyURLs
+	if cfg.ReadonlyURL != nil {
+		urls = append([]string{*cfg.ReadonlyURL}, urls...)
+	}
+
+	set := &replicaSet{
+		source:      source,
+		byPool:      make(map[gorm.ConnPool]*replica, len(urls)),
+		policy:      cfg.ReplicaPolicy,
+		healthCheck: cfg.ReplicaHealthCheck,
+	}
+
+	for i, url := range urls {
+		connCfg, err := pgx.ParseConfig(url)
+		if err != nil {
+			set.close()
+			return nil, fmt.Errorf("parse replica #%d url: %w", i, err)
+		}
+
+		r := &replica{index: i, db: stdlib.OpenDB(*connCfg)}
+		r.healthy.Store(true)
+
+		set.replicas = append(set.replicas, r)
+		set.byPool[r.db] = r
+	}
+
+	return set, nil
+}
+
+// dialectors returns the dialectors to be registered as dbresolver replicas.
+// The source database is registered last as the fallback used when all replicas are unhealthy.
+// It also makes dbresolver call the policy even if there is a single replica.
+func (s *replicaSet) dialectors() []gorm.Dialector {
+	if len(s.replicas) == 0 {
+		return nil
+	}
+
+	dialectors := make([]gorm.Dialector, 0, len(s.replicas)+1)
+	for _, r := range s.replicas {
+		dialectors = append(dialectors, postgres.New(postgres.Config{Conn: r.db}))
+	}
+
+	return append(dialectors, postgres.New(postgres.Config{Conn: s.source}))
+}
+
+func (s *replicaSet) Resolve(pools []gorm.ConnPool) gorm.ConnPool {
+	healthy := make([]*replica, 0, len(pools))
+	for _, pool := range pools {
+		if r, ok := s.byPool[pool]; ok && r.healthy.Load() {
+			healthy = append(healthy, r)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return s.source
+	}
+
+	switch s.policy {
+	case ReplicaPolicyRoundRobin:
+		return healthy[s.counter.Add(1)%uint64(len(healthy))].db
+	case ReplicaPolicyLeastConnections:
+		least := healthy[0]
+		for _, r := range healthy[1:] {
+			if r.db.Stats().InUse < least.db.Stats().InUse {
+				least = r
+			}
+		}
+		return least.db
+	default:
+		return healthy[rand.IntN(len(healthy))].db //nolint:gosec // no need for a secure random generator
+	}
+}
+
+// start starts probing the replicas if the health check is configured.
+func (s *replicaSet) start() {
+	if s.healthCheck == nil || len(s.replicas) == 0 {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.healthCheck.Interval)
+		defer ticker.Stop()
+
+		for {
+			for _, r := range s.replicas {
+				s.probe(r)
+			}
+
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// probe checks the replica availability and lag and evicts it from the routing if the check fails.
+func (s *replicaSet) probe(r *replica) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.healthCheck.Timeout)
+	defer cancel()
+
+	err := s.check(ctx, r)
+	if err != nil {
+		if r.healthy.Swap(false) {
+			logrus.WithError(err).WithField("replica", r.index).Error("replica evicted")
+		}
+		return
+	}
+
+	if !r.healthy.Swap(true) {
+		logrus.WithField("replica", r.index).Info("replica restored")
+	}
+}
+
+func (s *replicaSet) check(ctx context.Context, r *replica) error {
+	if s.healthCheck.MaxLag == 0 {
+		return r.db.PingContext(ctx)
+	}
+
+	var lagSeconds float64
+	if err := r.db.QueryRowContext(ctx, replicaLagQuery).Scan(&lagSeconds); err != nil {
+		return fmt.Errorf("query replication lag: %w", err)
+	}
+
+	if lag := time.Duration(lagSeconds * float64(time.Second)); lag > s.healthCheck.MaxLag {
+		return fmt.Errorf("replication lag %s exceeds %s", lag, s.healthCheck.MaxLag)
+	}
+
+	return nil
+}
+
+// ReplicaStatus describes the health of a read-only database instance.
+type ReplicaStatus struct {
+	// Index is the position of the replica in Config.ReadonlyURL followed by Config.ReadonlyURLs.
+	Index int
+
+	// Routed reports whether queries are routed to the replica, i.e. it passed the last health probe.
+	Routed bool
+
+	// Err is the error of the ping, nil if the replica is reachable.
+	Err error
+}
+
+// health pings the replicas, each ping is limited by the timeout of the health check.
+func (s *replicaSet) health() []ReplicaStatus {
+	timeout := defaultReplicaHealthCheckTimeout
+	if s.healthCheck != nil {
+		timeout = s.healthCheck.Timeout
+	}
+
+	statuses := make([]ReplicaStatus, 0, len(s.replicas))
+	for _, r := range s.replicas {
+		status := ReplicaStatus{Index: r.index, Routed: r.healthy.Load()}
+		if err := r.ping(timeout); err != nil {
+			status.Err = fmt.Errorf("ping replica #%d: %w", r.index, err)
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+func (r *replica) ping(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return r.db.PingContext(ctx)
+}
+
+func (s *replicaSet) close() error {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop = nil
+	}
+
+	var errs []error
+	for _, r := range s.replicas {
+		if err := r.db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close replica #%d: %w", r.index, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/kodenkai-labs/go-lib/internal/sqltest"
+)
+
+// newTestReplicaSet returns a replica set of the fake databases and the pools passed to Resolve by dbresolver.
+func newTestReplicaSet(t *testing.T, policy ReplicaPolicy, replicas ...*sqltest.DB) (*replicaSet, []gorm.ConnPool) {
+	t.Helper()
+
+	source := (&sqltest.DB{}).Open()
+	set := &replicaSet{
+		source: source,
+		byPool: make(map[gorm.ConnPool]*replica, len(replicas)),
+		policy: policy,
+	}
+
+	var pools []gorm.ConnPool
+	for i, fake := range replicas {
+		r := &replica{index: i, db: fake.Open()}
+		r.healthy.Store(true)
+
+		set.replicas = append(set.replicas, r)
+		set.byPool[r.db] = r
+		pools = append(pools, r.db)
+	}
+	t.Cleanup(func() {
+		_ = set.close()
+		_ = source.Close()
+	})
+
+	return set, append(pools, source)
+}
+
+func Test_replicaSet_Resolve(t *testing.T) {
+	t.Run("Round robin", func(t *testing.T) {
+		set, pools := newTestReplicaSet(t, ReplicaPolicyRoundRobin, &sqltest.DB{}, &sqltest.DB{}, &sqltest.DB{})
+
+		var resolved []gorm.ConnPool
+		for range 6 {
+			resolved = append(resolved, set.Resolve(pools))
+		}
+
+		assert.Equal(t, []gorm.ConnPool{pools[1], pools[2], pools[0], pools[1], pools[2], pools[0]}, resolved)
+	})
+
+	t.Run("Least connections", func(t *testing.T) {
+		set, pools := newTestReplicaSet(t, ReplicaPolicyLeastConnections, &sqltest.DB{}, &sqltest.DB{})
+
+		conn, err := set.replicas[0].db.Conn(context.Background())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		assert.Same(t, set.replicas[1].db, set.Resolve(pools))
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		set, pools := newTestReplicaSet(t, ReplicaPolicyRandom, &sqltest.DB{}, &sqltest.DB{})
+
+		for range 20 {
+			assert.Contains(t, pools[:2], set.Resolve(pools))
+		}
+	})
+
+	t.Run("Unhealthy replicas are skipped", func(t *testing.T) {
+		set, pools := newTestReplicaSet(t, ReplicaPolicyRoundRobin, &sqltest.DB{}, &sqltest.DB{}, &sqltest.DB{})
+		set.replicas[0].healthy.Store(false)
+		set.replicas[2].healthy.Store(false)
+
+		for range 3 {
+			assert.Same(t, set.replicas[1].db, set.Resolve(pools))
+		}
+	})
+
+	t.Run("Source when all replicas are unhealthy", func(t *testing.T) {
+		set, pools := newTestReplicaSet(t, ReplicaPolicyRoundRobin, &sqltest.DB{}, &sqltest.DB{})
+		for _, r := range set.replicas {
+			r.healthy.Store(false)
+		}
+
+		assert.Same(t, set.source, set.Resolve(pools))
+	})
+}
+
+// newLagDB returns a fake replica reporting the replication lag, or failing the lag query with err.
+func newLagDB(lag time.Duration, err error) *sqltest.DB {
+	return &sqltest.DB{
+		Query: func(int, string, []any) (driver.Rows, error) {
+			if err != nil {
+				return nil, err
+			}
+			return sqltest.NewRows([]string{"lag"}, []driver.Value{lag.Seconds()}), nil
+		},
+	}
+}
+
+func Test_replicaSet_check(t *testing.T) {
+	errQuery := errors.New("query failed")
+
+	tests := []struct {
+		name        string
+		db          *sqltest.DB
+		maxLag      time.Duration
+		closed      bool
+		wantErrText string
+	}{
+		{
+			name: "Ping",
+			db:   &sqltest.DB{},
+		},
+		{
+			name:        "Ping of unreachable replica",
+			db:          &sqltest.DB{},
+			closed:      true,
+			wantErrText: "database is closed",
+		},
+		{
+			name:   "Lag within limit",
+			db:     newLagDB(500*time.Millisecond, nil),
+			maxLag: time.Second,
+		},
+		{
+			name:        "Lag exceeds limit",
+			db:          newLagDB(2*time.Second, nil),
+			maxLag:      time.Second,
+			wantErrText: "replication lag 2s exceeds 1s",
+		},
+		{
+			name:        "Lag query failed",
+			db:          newLagDB(0, errQuery),
+			maxLag:      time.Second,
+			wantErrText: "query replication lag",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, _ := newTestReplicaSet(t, ReplicaPolicyRandom, tt.db)
+			set.healthCheck = &ReplicaHealthCheck{Timeout: time.Second, MaxLag: tt.maxLag}
+			if tt.closed {
+				require.NoError(t, set.replicas[0].db.Close())
+			}
+
+			err := set.check(context.Background(), set.replicas[0])
+			if tt.wantErrText == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErrText)
+			}
+		})
+	}
+}
+
+func Test_replicaSet_probe(t *testing.T) {
+	var lag time.Duration
+	fake := &sqltest.DB{
+		Query: func(int, string, []any) (driver.Rows, error) {
+			return sqltest.NewRows([]string{"lag"}, []driver.Value{lag.Seconds()}), nil
+		},
+	}
+
+	set, pools := newTestReplicaSet(t, ReplicaPolicyRandom, fake)
+	set.healthCheck = &ReplicaHealthCheck{Timeout: time.Second, MaxLag: time.Second}
+	r := set.replicas[0]
+
+	lag = 2 * time.Second
+	set.probe(r)
+	assert.False(t, r.healthy.Load(), "lagging replica is evicted")
+	assert.Same(t, set.source, set.Resolve(pools))
+
+	lag = 0
+	set.probe(r)
+	assert.True(t, r.healthy.Load(), "caught up replica is restored")
+	assert.Same(t, r.db, set.Resolve(pools))
+}
+
+func Test_replicaSet_health(t *testing.T) {
+	set, _ := newTestReplicaSet(t, ReplicaPolicyRandom, &sqltest.DB{}, &sqltest.DB{})
+	set.replicas[1].healthy.Store(false)
+	require.NoError(t, set.replicas[1].db.Close())
+
+	statuses := set.health()
+	require.Len(t, statuses, 2)
+	assert.Equal(t, ReplicaStatus{Index: 0, Routed: true}, statuses[0])
+	assert.Equal(t, 1, statuses[1].Index)
+	assert.False(t, statuses[1].Routed)
+	assert.ErrorContains(t, statuses[1].Err, "ping replica #1: sql: database is closed")
+}
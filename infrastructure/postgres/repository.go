@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kodenkai-labs/go-lib/errlib"
+)
+
+type repositoryConfig struct {
+	notFoundSlug errlib.Slug
+	conflictSlug errlib.Slug
+}
+
+// RepositoryOption represents a function that configures a Repository.
+type RepositoryOption func(cfg *repositoryConfig)
+
+// WithNotFoundSlug sets the slug of the errors returned when the entity is not found.
+// If not provided, errlib.SlugNotFound will be used by default.
+func WithNotFoundSlug(slug errlib.Slug) RepositoryOption {
+	return func(cfg *repositoryConfig) {
+		cfg.notFoundSlug = slug
+	}
+}
+
+// WithConflictSlug sets the slug of the errors returned when the entity violates a unique constraint.
+// If not provided, errlib.SlugAlreadyExists will be used by default.
+func WithConflictSlug(slug errlib.Slug) RepositoryOption {
+	return func(cfg *repositoryConfig) {
+		cfg.conflictSlug = slug
+	}
+}
+
+// Repository implements the common CRUD operations for the model T.
+// Operations use the transaction carried by the context, if any.
+// Errors are translated by TranslateError: gorm.ErrRecordNotFound is returned as errlib.AppError
+// with errlib.NotFoundCode, and unique violations are returned as errlib.AppError with errlib.ConflictCode.
+type Repository[T any] struct {
+	getter DBContextGetter
+	cfg    repositoryConfig
+}
+
+// NewRepository creates a new Repository for the model T.
+func NewRepository[T any](getter DBContextGetter, opts ...RepositoryOption) *Repository[T] {
+	cfg := repositoryConfig{
+		notFoundSlug: errlib.SlugNotFound,
+		conflictSlug: errlib.SlugAlreadyExists,
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return &Repository[T]{
+		getter: getter,
+		cfg:    cfg,
+	}
+}
+
+// DB returns the database of the model T to build custom queries.
+func (r *Repository[T]) DB(ctx context.Context) *gorm.DB {
+	return r.getter.DBFrom(ctx).Model(new(T))
+}
+
+// Get returns the first entity matching the conditions, ordered by the primary key.
+// The conditions have the same format as in gorm.DB.First, e.g. a primary key or a query with arguments.
+func (r *Repository[T]) Get(ctx context.Context, conds ...any) (*T, error) {
+	var entity T
+	if err := r.getter.DBFrom(ctx).First(&entity, conds...).Error; err != nil {
+		return nil, r.wrapError(fmt.Errorf("get entity: %w", err))
+	}
+
+	return &entity, nil
+}
+
+// List returns the entities selected by the given scopes, e.g. conditions, ordering and pagination.
+func (r *Repository[T]) List(ctx context.Context, scopes ...func(db *gorm.DB) *gorm.DB) ([]T, error) {
+	var entities []T
+	if err := r.getter.DBFrom(ctx).Scopes(scopes...).Find(&entities).Error; err != nil {
+		return nil, r.wrapError(fmt.Errorf("list entities: %w", err))
+	}
+
+	return entities, nil
+}
+
+// Create inserts the entity.
+func (r *Repository[T]) Create(ctx context.Context, entity *T) error {
+	if err := r.getter.DBFrom(ctx).Create(entity).Error; err != nil {
+		return r.wrapError(fmt.Errorf("create entity: %w", err))
+	}
+
+	return nil
+}
+
+// Update updates all fields of the entity identified by its primary key, including the zero ones,
+// except the creation time fields, e.g. CreatedAt, like Upsert does. So the entity must be loaded
+// or filled in completely, and the fields which may change concurrently should be updated with DB instead.
+func (r *Repository[T]) Update(ctx context.Context, entity *T) error {
+	db := r.getter.DBFrom(ctx)
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(entity); err != nil {
+		return fmt.Errorf("update entity: parse model: %w", err)
+	}
+
+	var omit []string
+	for _, field := range stmt.Schema.Fields {
+		if field.AutoCreateTime != 0 {
+			omit = append(omit, field.DBName)
+		}
+	}
+
+	result := db.Model(entity).Select("*").Omit(omit...).Updates(entity)
+	if result.Error != nil {
+		return r.wrapError(fmt.Errorf("update entity: %w", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return r.wrapError(fmt.Errorf("update entity: %w", gorm.ErrRecordNotFound))
+	}
+
+	return nil
+}
+
+// Delete deletes the entities matching the conditions.
+// The conditions have the same format as in gorm.DB.Delete, e.g. a primary key or a query with arguments.
+func (r *Repository[T]) Delete(ctx context.Context, conds ...any) error {
+	result := r.getter.DBFrom(ctx).Delete(new(T), conds...)
+	if result.Error != nil {
+		return r.wrapError(fmt.Errorf("delete entity: %w", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return r.wrapError(fmt.Errorf("delete entity: %w", gorm.ErrRecordNotFound))
+	}
+
+	return nil
+}
+
+// Upsert inserts the entity or updates all its fields if it conflicts on the given columns.
+// If no columns are given, the primary key is used.
+func (r *Repository[T]) Upsert(ctx context.Context, entity *T, conflictColumns ...string) error {
+	onConflict := clause.OnConflict{UpdateAll: true}
+	for _, column := range conflictColumns {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: column})
+	}
+
+	if err := r.getter.DBFrom(ctx).Clauses(onConflict).Create(entity).Error; err != nil {
+		return r.wrapError(fmt.Errorf("upsert entity: %w", err))
+	}
+
+	return nil
+}
+
+// wrapError translates the error by TranslateError, replacing the not found and conflict slugs
+// with the ones configured for the repository.
+func (r *Repository[T]) wrapError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errlib.NewAppError(err, errlib.NotFoundCode, r.cfg.notFoundSlug)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == sqlStateUniqueViolation {
+		return errlib.NewAppErrorWithDetail(err, errlib.ConflictCode, r.cfg.conflictSlug, pgErr.ConstraintName)
+	}
+
+	return TranslateError(err)
+}
@@ -0,0 +1,165 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kodenkai-labs/go-lib/errlib"
+	"github.com/kodenkai-labs/go-lib/infrastructure/postgres"
+	"github.com/kodenkai-labs/go-lib/internal/sqltest"
+)
+
+type user struct {
+	ID        uint64 `gorm:"primaryKey"`
+	Name      string
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func Test_Repository_Update(t *testing.T) {
+	fake := &sqltest.DB{}
+	getter := postgres.NewDBGetterFromGormInstance(fake.OpenGorm(t))
+
+	repository := postgres.NewRepository[user](getter)
+	require.NoError(t, repository.Update(context.Background(), &user{ID: 1, Name: "name"}))
+
+	statements := fake.Queries()
+	require.Len(t, statements, 1)
+
+	update := statements[0]
+	assert.True(t, strings.HasPrefix(update, `UPDATE "users" SET`), update)
+	assert.Contains(t, update, `"name"=`)
+	assert.Contains(t, update, `"active"=`, "zero fields are updated")
+	assert.Contains(t, update, `"updated_at"=`)
+	assert.NotContains(t, update, `"created_at"`)
+}
+
+func Test_Repository_Errors(t *testing.T) {
+	uniqueViolation := &pgconn.PgError{Code: "23505", ConstraintName: "users_name_key"}
+
+	// noRows makes the queries return no rows, conflict fails them with the unique violation,
+	// and nothingAffected makes the statements affect no rows.
+	noRows := func(*sqltest.DB) {}
+	conflict := func(fake *sqltest.DB) {
+		fake.Query = func(int, string, []any) (driver.Rows, error) {
+			return nil, uniqueViolation
+		}
+		fake.FailExec(uniqueViolation)
+	}
+	nothingAffected := func(fake *sqltest.DB) {
+		fake.Exec = func(int, string, []any) (driver.Result, error) {
+			return driver.RowsAffected(0), nil
+		}
+	}
+
+	tests := []struct {
+		name       string
+		setup      func(fake *sqltest.DB)
+		opts       []postgres.RepositoryOption
+		op         func(ctx context.Context, repository *postgres.Repository[user]) error
+		wantCode   errlib.Code
+		wantSlug   errlib.Slug
+		wantDetail string
+	}{
+		{
+			name:  "Get not found",
+			setup: noRows,
+			op: func(ctx context.Context, repository *postgres.Repository[user]) error {
+				_, err := repository.Get(ctx, 1)
+				return err
+			},
+			wantCode: errlib.NotFoundCode,
+			wantSlug: errlib.SlugNotFound,
+		},
+		{
+			name:  "Get not found with custom slug",
+			setup: noRows,
+			opts:  []postgres.RepositoryOption{postgres.WithNotFoundSlug("user_not_found")},
+			op: func(ctx context.Context, repository *postgres.Repository[user]) error {
+				_, err := repository.Get(ctx, 1)
+				return err
+			},
+			wantCode: errlib.NotFoundCode,
+			wantSlug: "user_not_found",
+		},
+		{
+			name:  "Create conflict",
+			setup: conflict,
+			op: func(ctx context.Context, repository *postgres.Repository[user]) error {
+				return repository.Create(ctx, &user{Name: "name"})
+			},
+			wantCode:   errlib.ConflictCode,
+			wantSlug:   errlib.SlugAlreadyExists,
+			wantDetail: "users_name_key",
+		},
+		{
+			name:  "Create conflict with custom slug",
+			setup: conflict,
+			opts:  []postgres.RepositoryOption{postgres.WithConflictSlug("user_exists")},
+			op: func(ctx context.Context, repository *postgres.Repository[user]) error {
+				return repository.Create(ctx, &user{Name: "name"})
+			},
+			wantCode:   errlib.ConflictCode,
+			wantSlug:   "user_exists",
+			wantDetail: "users_name_key",
+		},
+		{
+			name:  "Update not found",
+			setup: nothingAffected,
+			op: func(ctx context.Context, repository *postgres.Repository[user]) error {
+				return repository.Update(ctx, &user{ID: 1, Name: "name"})
+			},
+			wantCode: errlib.NotFoundCode,
+			wantSlug: errlib.SlugNotFound,
+		},
+		{
+			name:  "Delete not found",
+			setup: nothingAffected,
+			op: func(ctx context.Context, repository *postgres.Repository[user]) error {
+				return repository.Delete(ctx, 1)
+			},
+			wantCode: errlib.NotFoundCode,
+			wantSlug: errlib.SlugNotFound,
+		},
+		{
+			name:  "Upsert conflict",
+			setup: conflict,
+			opts:  []postgres.RepositoryOption{postgres.WithConflictSlug("user_exists")},
+			op: func(ctx context.Context, repository *postgres.Repository[user]) error {
+				return repository.Upsert(ctx, &user{ID: 1, Name: "name"}, "id")
+			},
+			wantCode:   errlib.ConflictCode,
+			wantSlug:   "user_exists",
+			wantDetail: "users_name_key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &sqltest.DB{
+				Query: func(int, string, []any) (driver.Rows, error) {
+					return sqltest.NewRows([]string{"id"}), nil
+				},
+			}
+			tt.setup(fake)
+			getter := postgres.NewDBGetterFromGormInstance(fake.OpenGorm(t))
+			repository := postgres.NewRepository[user](getter, tt.opts...)
+
+			err := tt.op(context.Background(), repository)
+
+			var appErr errlib.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, tt.wantCode, appErr.Code())
+			assert.Equal(t, tt.wantSlug, appErr.Slug())
+			assert.Equal(t, tt.wantDetail, errlib.Detail(err))
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/kodenkai-labs/go-lib/internal/backoff"
+)
+
+var transactionRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "postgres_transaction_retries_total",
+	Help: "Number of transaction retries caused by serialization failures and deadlocks.",
+}, []string{"code"})
+
+// RetryOptions represents the retry policy of TransactionWithRetry.
+type RetryOptions = backoff.Options
+
+// WithRetry sets the retry policy of TransactionWithRetry.
+// If not provided, 3 retries with backoff from 10ms up to 1s are used by default.
+func WithRetry(opts RetryOptions) TxOption {
+	return func(txOpts *TxOptions) {
+		txOpts.Retry = &opts
+	}
+}
+
+// IsRetryableError reports whether err is a serialization failure or a deadlock,
+// after which the whole transaction can be safely retried.
+func IsRetryableError(err error) bool {
+	return retryableCode(err) != ""
+}
+
+func retryableCode(err error) string {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return ""
+	}
+
+	switch pgErr.Code {
+	case sqlStateSerializationFailure, sqlStateDeadlockDetected:
+		return pgErr.Code
+	default:
+		return ""
+	}
+}
+
+// TransactionWithRetry runs fc in a transaction like TransactionWithOptions and reruns the whole transaction
+// when it fails with a serialization failure or a deadlock, waiting an exponentially growing jittered delay
+// between attempts. fc must be safe to run several times.
+// When the existing transaction is joined or a savepoint is created, fc is not retried,
+// since the failure aborts the outer transaction, which has to be retried as a whole.
+func (getter *DBGetter) TransactionWithRetry(
+	ctx context.Context, fc func(ctx context.Context) error, opts ...TxOption,
+) error {
+	var txOpts TxOptions
+	for _, opt := range opts {
+		opt(&txOpts)
+	}
+
+	if InTransaction(ctx) && txOpts.Propagation != PropagationRequiresNew {
+		return getter.TransactionWithOptions(ctx, fc, opts...)
+	}
+
+	retry := backoff.Default()
+	if txOpts.Retry != nil {
+		retry = *txOpts.Retry
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := getter.TransactionWithOptions(ctx, fc, opts...)
+
+		code := retryableCode(err)
+		if code == "" || attempt >= retry.MaxRetries {
+			return err
+		}
+
+		transactionRetries.WithLabelValues(code).Inc()
+
+		if waitErr := retry.Wait(ctx, attempt); waitErr != nil {
+			return errors.Join(err, waitErr)
+		}
+	}
+}
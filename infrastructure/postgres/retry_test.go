@@ -0,0 +1,136 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/postgres"
+	"github.com/kodenkai-labs/go-lib/internal/sqltest"
+)
+
+func Test_IsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "Serialization failure",
+			err:  &pgconn.PgError{Code: "40001"},
+			want: true,
+		},
+		{
+			name: "Wrapped deadlock",
+			err:  fmt.Errorf("update: %w", &pgconn.PgError{Code: "40P01"}),
+			want: true,
+		},
+		{
+			name: "Unique violation",
+			err:  &pgconn.PgError{Code: "23505"},
+			want: false,
+		},
+		{
+			name: "Not a postgres error",
+			err:  errors.New("some error"),
+			want: false,
+		},
+		{
+			name: "Nil error",
+			err:  nil,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, postgres.IsRetryableError(tt.err))
+		})
+	}
+}
+
+func Test_DBGetter_TransactionWithRetry(t *testing.T) {
+	const update = "UPDATE users SET name = 'name'"
+
+	serializationFailure := &pgconn.PgError{Code: "40001"}
+
+	tests := []struct {
+		name           string
+		execErrors     []error
+		nested         bool
+		wantErr        bool
+		wantCalls      int
+		wantStatements []string
+	}{
+		{
+			name:       "Translated serialization failure",
+			execErrors: []error{serializationFailure},
+			wantCalls:  2,
+			wantStatements: []string{
+				"BEGIN", update, "ROLLBACK",
+				"BEGIN", update, "COMMIT",
+			},
+		},
+		{
+			name:       "Retries exhausted",
+			execErrors: []error{serializationFailure, serializationFailure, serializationFailure},
+			wantErr:    true,
+			wantCalls:  3,
+			wantStatements: []string{
+				"BEGIN", update, "ROLLBACK",
+				"BEGIN", update, "ROLLBACK",
+				"BEGIN", update, "ROLLBACK",
+			},
+		},
+		{
+			name:           "Not retryable error",
+			execErrors:     []error{&pgconn.PgError{Code: "23505"}},
+			wantErr:        true,
+			wantCalls:      1,
+			wantStatements: []string{"BEGIN", update, "ROLLBACK"},
+		},
+		{
+			name:           "Joined transaction",
+			execErrors:     []error{serializationFailure},
+			nested:         true,
+			wantErr:        true,
+			wantCalls:      1,
+			wantStatements: []string{"BEGIN", update, "ROLLBACK"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &sqltest.DB{}
+			fake.FailExec(tt.execErrors...)
+			getter := postgres.NewDBGetterFromGormInstance(fake.OpenGorm(t))
+
+			calls := 0
+			transaction := func(ctx context.Context) error {
+				return getter.TransactionWithRetry(ctx, func(ctx context.Context) error {
+					calls++
+					return postgres.TranslateError(getter.DBFrom(ctx).Exec(update).Error)
+				}, postgres.WithRetry(postgres.RetryOptions{MaxRetries: 2}))
+			}
+
+			var err error
+			if tt.nested {
+				err = getter.Transaction(context.Background(), transaction)
+			} else {
+				err = transaction(context.Background())
+			}
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.Equal(t, tt.wantStatements, fake.Queries())
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+const stickyPurgeInterval = time.Minute
+
+// StickyStore keeps the time until which reads made on behalf of a key (a user, a session)
+// are routed to the source database. It allows to keep read-your-writes consistency across requests.
+type StickyStore interface {
+	Get(key string) time.Time
+	Set(key string, until time.Time)
+}
+
+// MemoryStickyStore is an in-memory StickyStore.
+// It is local to the application instance, so requests of the same key
+// must be routed to the same instance (e.g. by a sticky load balancer session).
+type MemoryStickyStore struct {
+	mu        sync.Mutex
+	items     map[string]time.Time
+	lastPurge time.Time
+}
+
+// NewMemoryStickyStore creates a new MemoryStickyStore.
+func NewMemoryStickyStore() *MemoryStickyStore {
+	return &MemoryStickyStore{
+		items:     make(map[string]time.Time),
+		lastPurge: time.Now(),
+	}
+}
+
+func (s *MemoryStickyStore) Get(key string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.items[key]
+}
+
+func (s *MemoryStickyStore) Set(key string, until time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastPurge) > stickyPurgeInterval {
+		for k, v := range s.items {
+			if v.Before(now) {
+				delete(s.items, k)
+			}
+		}
+		s.lastPurge = now
+	}
+
+	if until.After(s.items[key]) {
+		s.items[key] = until
+	}
+}
+
+type stickyContextKey struct{}
+
+var stickyKey = &stickyContextKey{}
+
+// stickyState is the read-your-writes state stored in the context.
+type stickyState struct {
+	primary bool
+	key     string
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+func stickyStateFrom(ctx context.Context) (*stickyState, bool) {
+	state, ok := ctx.Value(stickyKey).(*stickyState)
+	return state, ok
+}
+
+// WithPrimary returns a context, with which DBFrom always routes queries to the source database.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, stickyKey, &stickyState{primary: true})
+}
+
+// WithReadYourWrites returns a context, with which DBFrom routes queries to the source database
+// during Config.ReadYourWritesWindow after a write made with the same context.
+// If key is not empty and the DBGetter is created with WithStickyStore, the write is remembered for the key,
+// so the following contexts with the same key are routed to the source database too.
+func WithReadYourWrites(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, stickyKey, &stickyState{key: key})
+}
+
+// WithStickyStore sets the store used to keep read-your-writes consistency across requests
+// with the same key (see WithReadYourWrites).
+func WithStickyStore(store StickyStore) Option {
+	return func(getter *DBGetter) {
+		getter.stickyStore = store
+	}
+}
+
+// usePrimary reports whether queries made with the sticky state must be routed to the source database.
+func (getter *DBGetter) usePrimary(state *stickyState) bool {
+	if state.primary {
+		return true
+	}
+
+	now := time.Now()
+
+	state.mu.Lock()
+	until := state.until
+	state.mu.Unlock()
+
+	if now.Before(until) {
+		return true
+	}
+
+	return state.key != "" && getter.stickyStore != nil && now.Before(getter.stickyStore.Get(state.key))
+}
+
+// markWrite makes the queries made with the context be routed to the source database
+// during the read-your-writes window.
+func (getter *DBGetter) markWrite(ctx context.Context) {
+	state, ok := stickyStateFrom(ctx)
+	if !ok || state.primary {
+		return
+	}
+
+	until := time.Now().Add(getter.stickyWindow)
+
+	state.mu.Lock()
+	state.until = until
+	state.mu.Unlock()
+
+	if state.key != "" && getter.stickyStore != nil {
+		getter.stickyStore.Set(state.key, until)
+	}
+}
+
+// registerStickyCallbacks registers the callbacks detecting writes.
+// Writes made in transactions are also detected when the outermost transaction is committed.
+func (getter *DBGetter) registerStickyCallbacks() error {
+	const name = "go-lib:read_your_writes"
+
+	markWrite := func(db *gorm.DB) {
+		if db.Error == nil && db.Statement.Context != nil {
+			getter.markWrite(db.Statement.Context)
+		}
+	}
+
+	callbacks := getter.db.Callback()
+	if err := callbacks.Create().After("gorm:create").Register(name, markWrite); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register(name, markWrite); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:delete").Register(name, markWrite); err != nil {
+		return err
+	}
+
+	return callbacks.Raw().After("gorm:raw").Register(name, func(db *gorm.DB) {
+		sql := strings.TrimSpace(db.Statement.SQL.String())
+		if len(sql) >= len("select") && strings.EqualFold(sql[:len("select")], "select") {
+			return
+		}
+		markWrite(db)
+	})
+}
+
+// stickyDB returns the database to be used with the sticky state.
+func (getter *DBGetter) stickyDB(ctx context.Context, state *stickyState) *gorm.DB {
+	db := getter.db.WithContext(ctx)
+	if getter.usePrimary(state) {
+		db = db.Clauses(dbresolver.Write)
+	}
+
+	return db
+}
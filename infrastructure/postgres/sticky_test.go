@@ -0,0 +1,57 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gormpostgres "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/postgres"
+	"github.com/kodenkai-labs/go-lib/internal/sqltest"
+)
+
+func Test_MemoryStickyStore(t *testing.T) {
+	store := postgres.NewMemoryStickyStore()
+
+	assert.True(t, store.Get("user").IsZero())
+
+	until := time.Now().Add(time.Minute)
+	store.Set("user", until)
+	assert.Equal(t, until, store.Get("user"))
+
+	// an earlier deadline doesn't shorten the window
+	store.Set("user", until.Add(-time.Second))
+	assert.Equal(t, until, store.Get("user"))
+
+	assert.True(t, store.Get("another_user").IsZero())
+}
+
+func Test_DBGetter_ReadYourWrites(t *testing.T) {
+	source, replica := &sqltest.DB{}, &sqltest.DB{}
+
+	db := source.OpenGorm(t)
+	require.NoError(t, db.Use(dbresolver.Register(dbresolver.Config{
+		Sources:  []gorm.Dialector{gormpostgres.New(gormpostgres.Config{Conn: source.Open()})},
+		Replicas: []gorm.Dialector{gormpostgres.New(gormpostgres.Config{Conn: replica.Open()})},
+	})))
+
+	getter, err := postgres.NewDBGetterFromGormInstanceWithOptions(db)
+	require.NoError(t, err)
+
+	var n int
+	require.NoError(t, getter.DBFrom(context.Background()).Raw("SELECT id FROM users").Scan(&n).Error)
+	assert.Equal(t, []string{"SELECT id FROM users"}, replica.Queries(), "reads are routed to the replica")
+
+	ctx := postgres.WithReadYourWrites(context.Background(), "")
+	require.NoError(t, getter.DBFrom(ctx).Exec("UPDATE users SET name = 'name'").Error)
+	require.NoError(t, getter.DBFrom(ctx).Raw("SELECT id FROM orders").Scan(&n).Error)
+
+	assert.Equal(t, []string{"UPDATE users SET name = 'name'", "SELECT id FROM orders"}, source.Queries(),
+		"the read following the write is routed to the source")
+	assert.Equal(t, []string{"SELECT id FROM users"}, replica.Queries())
+}
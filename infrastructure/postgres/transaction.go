@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var ErrTransactionExists = errors.New("transaction already exists")
+
+// Propagation defines how a transaction behaves when the context already carries a transaction.
+type Propagation int
+
+const (
+	// PropagationRequired joins the existing transaction or begins a new one if there is none.
+	PropagationRequired Propagation = iota
+	// PropagationRequiresNew always begins a new independent transaction on a separate connection.
+	// The outer transaction, if any, is not affected by the result of the new one.
+	PropagationRequiresNew
+	// PropagationNested creates a savepoint in the existing transaction or begins a new one if there is none.
+	// If fc fails, only the changes made after the savepoint are rolled back.
+	PropagationNested
+	// PropagationNever runs fc without a transaction and fails with ErrTransactionExists
+	// if the context already carries a transaction.
+	PropagationNever
+)
+
+// TxOptions represents the options of a transaction.
+type TxOptions struct {
+	Propagation Propagation
+
+	// Isolation is the isolation level of a new transaction.
+	// It is ignored when the existing transaction is joined or a savepoint is created.
+	Isolation sql.IsolationLevel
+
+	// ReadOnly makes a new transaction read-only.
+	// It is ignored when the existing transaction is joined or a savepoint is created.
+	ReadOnly bool
+
+	// Retry is the retry policy used by TransactionWithRetry.
+	// It is ignored by TransactionWithOptions.
+	Retry *RetryOptions
+}
+
+// TxOption represents a function that configures TxOptions.
+type TxOption func(*TxOptions)
+
+// WithPropagation sets the propagation mode of the transaction.
+// If not provided, PropagationRequired will be used by default.
+func WithPropagation(propagation Propagation) TxOption {
+	return func(opts *TxOptions) {
+		opts.Propagation = propagation
+	}
+}
+
+// WithIsolation sets the isolation level of the transaction.
+// If not provided, the default isolation level of the database will be used.
+func WithIsolation(isolation sql.IsolationLevel) TxOption {
+	return func(opts *TxOptions) {
+		opts.Isolation = isolation
+	}
+}
+
+// WithReadOnly makes the transaction read-only.
+func WithReadOnly() TxOption {
+	return func(opts *TxOptions) {
+		opts.ReadOnly = true
+	}
+}
+
+// InTransaction reports whether the context carries a transaction started by DBGetter.
+func InTransaction(ctx context.Context) bool {
+	_, ok := trxStateFrom(ctx)
+	return ok
+}
+
+// TransactionWithOptions runs fc in a transaction configured by the given options.
+// The transaction is propagated to fc through the context and can be retrieved by DBFrom.
+func (getter *DBGetter) TransactionWithOptions(
+	ctx context.Context, fc func(ctx context.Context) error, opts ...TxOption,
+) error {
+	var txOpts TxOptions
+	for _, opt := range opts {
+		opt(&txOpts)
+	}
+
+	sqlOpts := &sql.TxOptions{
+		Isolation: txOpts.Isolation,
+		ReadOnly:  txOpts.ReadOnly,
+	}
+
+	switch txOpts.Propagation {
+	case PropagationRequired:
+		if InTransaction(ctx) {
+			return fc(ctx)
+		}
+		return getter.begin(ctx, nil, fc, sqlOpts)
+	case PropagationRequiresNew:
+		return getter.begin(ctx, nil, fc, sqlOpts)
+	case PropagationNested:
+		parent, _ := trxStateFrom(ctx)
+		return getter.begin(ctx, parent, fc, sqlOpts)
+	case PropagationNever:
+		if InTransaction(ctx) {
+			return ErrTransactionExists
+		}
+		return fc(ctx)
+	default:
+		return errors.New("invalid transaction propagation")
+	}
+}
+
+// begin begins a new transaction or, if parent is not nil, creates a savepoint in the parent transaction.
+func (getter *DBGetter) begin(
+	ctx context.Context, parent *trxState, fc func(ctx context.Context) error, opts *sql.TxOptions,
+) error {
+	db := getter.db.WithContext(ctx)
+	hooks := newTrxHooks(nil)
+	if parent != nil {
+		// gorm creates a savepoint when Transaction is called on a transaction
+		db = parent.db
+		hooks = newTrxHooks(parent.hooks)
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return fc(context.WithValue(ctx, trxKey, &trxState{db: tx, hooks: hooks}))
+	}, opts)
+
+	if parent != nil {
+		parent.hooks.merge(hooks, err == nil)
+	} else {
+		if err == nil && !opts.ReadOnly {
+			getter.markWrite(ctx)
+		}
+		hooks.run(ctx, err == nil)
+	}
+
+	return err
+}
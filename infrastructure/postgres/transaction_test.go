@@ -0,0 +1,143 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kodenkai-labs/go-lib/infrastructure/postgres"
+	"github.com/kodenkai-labs/go-lib/internal/sqltest"
+)
+
+func Test_DBGetter_TransactionWithOptions(t *testing.T) {
+	const (
+		outerUpdate = "UPDATE users SET name = 'outer'"
+		innerUpdate = "UPDATE users SET name = 'inner'"
+	)
+
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name string
+		// outer runs the transaction under test in an outer transaction.
+		outer    bool
+		opts     []postgres.TxOption
+		innerErr error
+		wantErr  error
+		// wantStatements are the executed statements with the savepoint names cut off.
+		wantStatements []string
+		// wantSeparateConn is set when the transaction under test runs on another connection than the outer one.
+		wantSeparateConn bool
+	}{
+		{
+			name:           "Required",
+			wantStatements: []string{"BEGIN", innerUpdate, "COMMIT"},
+		},
+		{
+			name:           "Required joins the existing transaction",
+			outer:          true,
+			wantStatements: []string{"BEGIN", outerUpdate, innerUpdate, "COMMIT"},
+		},
+		{
+			name:             "Requires new",
+			outer:            true,
+			opts:             []postgres.TxOption{postgres.WithPropagation(postgres.PropagationRequiresNew)},
+			wantStatements:   []string{"BEGIN", outerUpdate, "BEGIN", innerUpdate, "COMMIT", "COMMIT"},
+			wantSeparateConn: true,
+		},
+		{
+			name:           "Nested without transaction",
+			opts:           []postgres.TxOption{postgres.WithPropagation(postgres.PropagationNested)},
+			wantStatements: []string{"BEGIN", innerUpdate, "COMMIT"},
+		},
+		{
+			name:     "Nested rolled back to savepoint",
+			outer:    true,
+			opts:     []postgres.TxOption{postgres.WithPropagation(postgres.PropagationNested)},
+			innerErr: errFailed,
+			wantErr:  errFailed,
+			wantStatements: []string{
+				"BEGIN", outerUpdate, "SAVEPOINT", innerUpdate, "ROLLBACK TO SAVEPOINT", "COMMIT",
+			},
+		},
+		{
+			name:           "Never",
+			opts:           []postgres.TxOption{postgres.WithPropagation(postgres.PropagationNever)},
+			wantStatements: []string{innerUpdate},
+		},
+		{
+			name:           "Never in transaction",
+			outer:          true,
+			opts:           []postgres.TxOption{postgres.WithPropagation(postgres.PropagationNever)},
+			wantErr:        postgres.ErrTransactionExists,
+			wantStatements: []string{"BEGIN", outerUpdate, "COMMIT"},
+		},
+		{
+			name:           "Isolation and read only",
+			opts:           []postgres.TxOption{postgres.WithIsolation(sql.LevelSerializable), postgres.WithReadOnly()},
+			wantStatements: []string{"BEGIN ISOLATION LEVEL SERIALIZABLE READ ONLY", innerUpdate, "COMMIT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &sqltest.DB{}
+			getter := postgres.NewDBGetterFromGormInstance(fake.OpenGorm(t))
+
+			inner := func(ctx context.Context) error {
+				return getter.TransactionWithOptions(ctx, func(ctx context.Context) error {
+					if err := getter.DBFrom(ctx).Exec(innerUpdate).Error; err != nil {
+						return err
+					}
+					return tt.innerErr
+				}, tt.opts...)
+			}
+
+			var err error
+			if tt.outer {
+				outerErr := getter.Transaction(context.Background(), func(ctx context.Context) error {
+					if err := getter.DBFrom(ctx).Exec(outerUpdate).Error; err != nil {
+						return err
+					}
+					// the outer transaction commits regardless of the result of the inner one
+					err = inner(ctx)
+					return nil
+				})
+				assert.NoError(t, outerErr)
+			} else {
+				err = inner(context.Background())
+			}
+
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+
+			var (
+				statements []string
+				conns      = map[string]int{}
+			)
+			for _, statement := range fake.Statements() {
+				query := statement.Query
+				for _, savepoint := range []string{"ROLLBACK TO SAVEPOINT", "SAVEPOINT"} {
+					if strings.HasPrefix(query, savepoint+" ") {
+						query = savepoint
+						break
+					}
+				}
+				statements = append(statements, query)
+				conns[statement.Query] = statement.Conn
+			}
+			assert.Equal(t, tt.wantStatements, statements)
+
+			if _, ran := conns[innerUpdate]; ran && tt.outer {
+				assert.Equal(t, tt.wantSeparateConn, conns[outerUpdate] != conns[innerUpdate])
+			}
+		})
+	}
+}
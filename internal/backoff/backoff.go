@@ -0,0 +1,66 @@
+// Package backoff provides the retry policy with exponentially growing delays
+// shared by the postgres and mongo transactions and the outbox relay.
+package backoff
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Options represents the retry policy.
+type Options struct {
+	// MaxRetries is the maximum number of retries after the first attempt.
+	MaxRetries int
+
+	// MinBackoff is the upper limit of the delay before the first retry.
+	// Zero disables the delays, so the retries run immediately.
+	MinBackoff time.Duration
+
+	// MaxBackoff is the upper limit of the exponentially growing delay.
+	MaxBackoff time.Duration
+}
+
+// Default returns 3 retries with backoff from 10ms up to 1s.
+func Default() Options {
+	return Options{
+		MaxRetries: 3,
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: time.Second,
+	}
+}
+
+// Limit returns the delay before the retry following the given failed attempt, counted from zero:
+// MinBackoff doubled after every attempt up to MaxBackoff.
+func (opts Options) Limit(attempt int) time.Duration {
+	limit := opts.MinBackoff
+	for i := 0; i < attempt && limit < opts.MaxBackoff; i++ {
+		limit *= 2
+	}
+
+	return min(limit, opts.MaxBackoff)
+}
+
+// Jitter returns a random delay up to Limit ("full jitter"), so that the concurrent retries spread out.
+func (opts Options) Jitter(attempt int) time.Duration {
+	limit := opts.Limit(attempt)
+	if limit <= 0 {
+		return 0
+	}
+
+	return rand.N(limit) //nolint:gosec // jitter doesn't need a secure random generator
+}
+
+// Wait waits the jittered delay before the retry following the given failed attempt.
+// It returns the error of the context if it's done first.
+func (opts Options) Wait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(opts.Jitter(attempt))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,57 @@
+package backoff_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kodenkai-labs/go-lib/internal/backoff"
+)
+
+func Test_Options_Limit(t *testing.T) {
+	opts := backoff.Options{MinBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: 10 * time.Millisecond},
+		{attempt: 1, want: 20 * time.Millisecond},
+		{attempt: 2, want: 40 * time.Millisecond},
+		{attempt: 3, want: 50 * time.Millisecond},
+		{attempt: 100, want: 50 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, opts.Limit(tt.attempt), "attempt %d", tt.attempt)
+	}
+}
+
+func Test_Options_Jitter(t *testing.T) {
+	opts := backoff.Options{MinBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+
+	for attempt := range 5 {
+		for range 100 {
+			jitter := opts.Jitter(attempt)
+			assert.GreaterOrEqual(t, jitter, time.Duration(0))
+			assert.Less(t, jitter, opts.Limit(attempt), "attempt %d", attempt)
+		}
+	}
+}
+
+func Test_Options_Jitter_Disabled(t *testing.T) {
+	assert.Zero(t, backoff.Options{}.Jitter(0))
+	assert.Zero(t, backoff.Options{MaxBackoff: time.Second}.Jitter(3))
+}
+
+func Test_Options_Wait(t *testing.T) {
+	assert.NoError(t, backoff.Options{}.Wait(context.Background(), 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	opts := backoff.Options{MinBackoff: time.Hour, MaxBackoff: time.Hour}
+	assert.ErrorIs(t, opts.Wait(ctx, 0), context.Canceled)
+}
@@ -0,0 +1,259 @@
+// Package sqltest provides a fake database/sql connector for the tests of the packages
+// working with postgres, so they can run without a database.
+package sqltest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	gormpostgres "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Statement is a statement executed by the fake database.
+type Statement struct {
+	Query string
+	Args  []any
+
+	// Conn identifies the connection which executed the statement.
+	Conn int
+}
+
+// DB is a database/sql connector recording the executed statements, including "BEGIN", "COMMIT" and "ROLLBACK".
+// By default, Exec affects one row, failing with the errors queued by FailExec in order,
+// and queries return a single row with the value 1. The handlers must be set before the database is opened.
+type DB struct {
+	// Exec handles the statements executed by Exec, if set.
+	Exec func(conn int, query string, args []any) (driver.Result, error)
+
+	// Query handles the queries, if set.
+	Query func(conn int, query string, args []any) (driver.Rows, error)
+
+	// Close is called when the connection is closed, if set.
+	Close func(conn int)
+
+	mu         sync.Mutex
+	statements []Statement
+	execErrors []error
+	conns      int
+}
+
+// FailExec queues the errors returned by the next statements executed by Exec.
+func (db *DB) FailExec(errs ...error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.execErrors = append(db.execErrors, errs...)
+}
+
+// Statements returns the executed statements.
+func (db *DB) Statements() []Statement {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return append([]Statement(nil), db.statements...)
+}
+
+// Queries returns the queries of the executed statements.
+func (db *DB) Queries() []string {
+	statements := db.Statements()
+
+	queries := make([]string, 0, len(statements))
+	for _, statement := range statements {
+		queries = append(queries, statement.Query)
+	}
+	return queries
+}
+
+// QueriesWithPrefix returns the executed statements whose query starts with the prefix.
+func (db *DB) QueriesWithPrefix(prefix string) []Statement {
+	var found []Statement
+	for _, statement := range db.Statements() {
+		if strings.HasPrefix(statement.Query, prefix) {
+			found = append(found, statement)
+		}
+	}
+	return found
+}
+
+// Open opens a connection pool of the database, e.g. the one of another replica.
+func (db *DB) Open() *sql.DB {
+	return sql.OpenDB(db)
+}
+
+// OpenGorm opens a gorm database on top of the fake connector.
+func (db *DB) OpenGorm(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	gormDB, err := gorm.Open(gormpostgres.New(gormpostgres.Config{Conn: db.Open()}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open gorm: %v", err)
+	}
+
+	return gormDB
+}
+
+func (db *DB) Connect(context.Context) (driver.Conn, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.conns++
+	return &conn{db: db, id: db.conns}, nil
+}
+
+func (db *DB) Driver() driver.Driver {
+	return nil
+}
+
+func (db *DB) record(conn int, query string, args []driver.NamedValue) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.statements = append(db.statements, Statement{Query: query, Args: values(args), Conn: conn})
+}
+
+func (db *DB) nextExecError() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if len(db.execErrors) == 0 {
+		return nil
+	}
+
+	err := db.execErrors[0]
+	db.execErrors = db.execErrors[1:]
+	return err
+}
+
+// NewRows returns the rows of the columns with the values.
+func NewRows(columns []string, values ...[]driver.Value) driver.Rows {
+	return &rows{columns: columns, values: values}
+}
+
+type conn struct {
+	db *DB
+	id int
+}
+
+func (c *conn) Prepare(string) (driver.Stmt, error) {
+	return nil, driver.ErrSkip
+}
+
+// Close ends the session, calling the Close handler.
+func (c *conn) Close() error {
+	if c.db.Close != nil {
+		c.db.Close(c.id)
+	}
+	return nil
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx records "BEGIN" followed by the non-default options, e.g. "BEGIN ISOLATION LEVEL SERIALIZABLE READ ONLY".
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	query := "BEGIN"
+	if level := sql.IsolationLevel(opts.Isolation); level != sql.LevelDefault {
+		query += " ISOLATION LEVEL " + strings.ToUpper(level.String())
+	}
+	if opts.ReadOnly {
+		query += " READ ONLY"
+	}
+
+	c.db.record(c.id, query, nil)
+	return &tx{conn: c}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.db.record(c.id, query, args)
+
+	if err := c.db.nextExecError(); err != nil {
+		return nil, err
+	}
+	if c.db.Exec != nil {
+		return c.db.Exec(c.id, query, values(args))
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.db.record(c.id, query, args)
+
+	if c.db.Query != nil {
+		return c.db.Query(c.id, query, values(args))
+	}
+
+	return NewRows([]string{"value"}, []driver.Value{int64(1)}), nil
+}
+
+func values(args []driver.NamedValue) []any {
+	values := make([]any, 0, len(args))
+	for _, arg := range args {
+		values = append(values, arg.Value)
+	}
+	return values
+}
+
+type tx struct {
+	conn *conn
+}
+
+func (tx *tx) Commit() error {
+	tx.conn.db.record(tx.conn.id, "COMMIT", nil)
+	return nil
+}
+
+func (tx *tx) Rollback() error {
+	tx.conn.db.record(tx.conn.id, "ROLLBACK", nil)
+	return nil
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
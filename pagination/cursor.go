@@ -0,0 +1,254 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kodenkai-labs/go-lib/errlib"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrEmptySecret is returned by NewCodec if the secret is empty, since the cursors signed with it can be forged.
+	ErrEmptySecret = errors.New("empty cursor secret")
+)
+
+// Value types supported in cursors.
+const (
+	valueTypeString   = "s"
+	valueTypeInt      = "i"
+	valueTypeFloat    = "f"
+	valueTypeBool     = "b"
+	valueTypeTime     = "t"
+	valueTypeObjectID = "o"
+)
+
+// Cursor points to the position in the sorted items from which the next page starts.
+type Cursor struct {
+	// Sort is the sort the cursor is created for, in the format accepted by ParseSort.
+	Sort string
+
+	// Values contains the values of the sort fields of the item the page starts after.
+	// Supported types are string, integers, floats, bool, time.Time and primitive.ObjectID.
+	// Integers are decoded as int64 and floats as float64. Nil isn't supported, see Keyset.
+	Values []any
+
+	// Backward means the page contains the items preceding the item.
+	Backward bool
+}
+
+type cursorPayload struct {
+	Sort     string       `json:"s"`
+	Values   []typedValue `json:"v"`
+	Backward bool         `json:"b,omitempty"`
+}
+
+type typedValue struct {
+	Type  string          `json:"t"`
+	Value json.RawMessage `json:"v,omitempty"`
+}
+
+// Codec encodes cursors to opaque tokens signed with HMAC-SHA256, so clients can't forge them.
+type Codec struct {
+	secret []byte
+}
+
+// NewCodec creates a new Codec signing cursors with the secret.
+// It fails with ErrEmptySecret if the secret is empty.
+func NewCodec(secret []byte) (*Codec, error) {
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+
+	return &Codec{secret: secret}, nil
+}
+
+// Encode encodes the cursor to a URL-safe token.
+func (c *Codec) Encode(cursor Cursor) (string, error) {
+	payload := cursorPayload{
+		Sort:     cursor.Sort,
+		Values:   make([]typedValue, 0, len(cursor.Values)),
+		Backward: cursor.Backward,
+	}
+
+	for _, value := range cursor.Values {
+		typed, err := encodeValue(value)
+		if err != nil {
+			return "", err
+		}
+		payload.Values = append(payload.Values, typed)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("marshal cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data) + "." + base64.RawURLEncoding.EncodeToString(c.sign(data)), nil
+}
+
+// Decode decodes the token created by Encode.
+// It returns errlib.AppError with errlib.InvalidInputCode if the token is malformed or its signature is invalid.
+func (c *Codec) Decode(token string) (Cursor, error) {
+	cursor, err := c.decode(token)
+	if err != nil {
+		return Cursor{}, errlib.NewAppError(
+			fmt.Errorf("%w: %w", ErrInvalidCursor, err), errlib.InvalidInputCode, errlib.SlugInvalidCursor)
+	}
+
+	return cursor, nil
+}
+
+func (c *Codec) decode(token string) (Cursor, error) {
+	encodedData, encodedSignature, found := strings.Cut(token, ".")
+	if !found {
+		return Cursor{}, errors.New("malformed token")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encodedData)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("decode payload: %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("decode signature: %w", err)
+	}
+
+	if !hmac.Equal(signature, c.sign(data)) {
+		return Cursor{}, errors.New("signature mismatch")
+	}
+
+	var payload cursorPayload
+	if err = json.Unmarshal(data, &payload); err != nil {
+		return Cursor{}, fmt.Errorf("unmarshal payload: %w", err)
+	}
+
+	cursor := Cursor{
+		Sort:     payload.Sort,
+		Values:   make([]any, 0, len(payload.Values)),
+		Backward: payload.Backward,
+	}
+	for _, typed := range payload.Values {
+		value, err := decodeValue(typed)
+		if err != nil {
+			return Cursor{}, err
+		}
+		cursor.Values = append(cursor.Values, value)
+	}
+
+	return cursor, nil
+}
+
+func (c *Codec) sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(data)
+
+	return mac.Sum(nil)
+}
+
+//nolint:cyclop // flat type switch
+func encodeValue(value any) (typedValue, error) {
+	var (
+		valueType string
+		raw       any
+	)
+
+	switch v := value.(type) {
+	case nil:
+		return typedValue{}, errors.New("cursor value is nil, sort fields must not contain NULL values")
+	case string:
+		valueType, raw = valueTypeString, v
+	case int:
+		valueType, raw = valueTypeInt, int64(v)
+	case int8:
+		valueType, raw = valueTypeInt, int64(v)
+	case int16:
+		valueType, raw = valueTypeInt, int64(v)
+	case int32:
+		valueType, raw = valueTypeInt, int64(v)
+	case int64:
+		valueType, raw = valueTypeInt, v
+	case uint:
+		if uint64(v) > math.MaxInt64 {
+			return typedValue{}, fmt.Errorf("cursor value %d overflows int64", v)
+		}
+		valueType, raw = valueTypeInt, int64(v)
+	case uint8:
+		valueType, raw = valueTypeInt, int64(v)
+	case uint16:
+		valueType, raw = valueTypeInt, int64(v)
+	case uint32:
+		valueType, raw = valueTypeInt, int64(v)
+	case uint64:
+		if v > math.MaxInt64 {
+			return typedValue{}, fmt.Errorf("cursor value %d overflows int64", v)
+		}
+		valueType, raw = valueTypeInt, int64(v)
+	case float32:
+		valueType, raw = valueTypeFloat, float64(v)
+	case float64:
+		valueType, raw = valueTypeFloat, v
+	case bool:
+		valueType, raw = valueTypeBool, v
+	case time.Time:
+		valueType, raw = valueTypeTime, v.Format(time.RFC3339Nano)
+	case primitive.ObjectID:
+		valueType, raw = valueTypeObjectID, v.Hex()
+	default:
+		return typedValue{}, fmt.Errorf("unsupported cursor value type %T", value)
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return typedValue{}, fmt.Errorf("marshal cursor value: %w", err)
+	}
+
+	return typedValue{Type: valueType, Value: data}, nil
+}
+
+func decodeValue(typed typedValue) (any, error) {
+	switch typed.Type {
+	case valueTypeString:
+		return unmarshalValue[string](typed.Value)
+	case valueTypeInt:
+		return unmarshalValue[int64](typed.Value)
+	case valueTypeFloat:
+		return unmarshalValue[float64](typed.Value)
+	case valueTypeBool:
+		return unmarshalValue[bool](typed.Value)
+	case valueTypeTime:
+		raw, err := unmarshalValue[string](typed.Value)
+		if err != nil {
+			return nil, err
+		}
+		return time.Parse(time.RFC3339Nano, raw)
+	case valueTypeObjectID:
+		raw, err := unmarshalValue[string](typed.Value)
+		if err != nil {
+			return nil, err
+		}
+		return primitive.ObjectIDFromHex(raw)
+	default:
+		return nil, fmt.Errorf("unknown cursor value type %q", typed.Type)
+	}
+}
+
+func unmarshalValue[T any](data json.RawMessage) (T, error) {
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, fmt.Errorf("unmarshal cursor value: %w", err)
+	}
+
+	return value, nil
+}
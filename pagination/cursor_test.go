@@ -0,0 +1,127 @@
+package pagination_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kodenkai-labs/go-lib/errlib"
+	"github.com/kodenkai-labs/go-lib/pagination"
+)
+
+func newCodec(t *testing.T, secret []byte) *pagination.Codec {
+	t.Helper()
+
+	codec, err := pagination.NewCodec(secret)
+	require.NoError(t, err)
+
+	return codec
+}
+
+func Test_NewCodec_EmptySecret(t *testing.T) {
+	for _, secret := range [][]byte{nil, {}} {
+		codec, err := pagination.NewCodec(secret)
+		assert.ErrorIs(t, err, pagination.ErrEmptySecret)
+		assert.Nil(t, codec)
+	}
+}
+
+func Test_Codec(t *testing.T) {
+	codec := newCodec(t, []byte("secret"))
+
+	createdAt := time.Date(2024, 5, 1, 10, 30, 0, 123, time.UTC)
+	objectID := primitive.NewObjectID()
+	cursor := pagination.Cursor{
+		Sort:     "-created_at,id",
+		Values:   []any{createdAt, 42, "name", 1.5, true, objectID, uint64(7), int16(-3)},
+		Backward: true,
+	}
+
+	token, err := codec.Encode(cursor)
+	require.NoError(t, err)
+
+	decoded, err := codec.Decode(token)
+	require.NoError(t, err)
+
+	assert.Equal(t, cursor.Sort, decoded.Sort)
+	assert.True(t, decoded.Backward)
+	require.Len(t, decoded.Values, len(cursor.Values))
+	assert.True(t, createdAt.Equal(decoded.Values[0].(time.Time)))
+	assert.Equal(t, int64(42), decoded.Values[1])
+	assert.Equal(t, "name", decoded.Values[2])
+	assert.InDelta(t, 1.5, decoded.Values[3], 0)
+	assert.Equal(t, true, decoded.Values[4])
+	assert.Equal(t, objectID, decoded.Values[5])
+	assert.Equal(t, int64(7), decoded.Values[6])
+	assert.Equal(t, int64(-3), decoded.Values[7])
+}
+
+func Test_Codec_InvalidToken(t *testing.T) {
+	codec := newCodec(t, []byte("secret"))
+
+	token, err := codec.Encode(pagination.Cursor{Sort: "id", Values: []any{1}})
+	require.NoError(t, err)
+
+	forged, err := newCodec(t, []byte("another_secret")).Encode(pagination.Cursor{Sort: "id", Values: []any{2}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{
+			name:  "Malformed token",
+			token: "malformed",
+		},
+		{
+			name:  "Tampered payload",
+			token: "x" + token,
+		},
+		{
+			name:  "Signed with another secret",
+			token: forged,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := codec.Decode(tt.token)
+
+			var appErr errlib.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, errlib.InvalidInputCode, appErr.Code())
+			assert.Equal(t, errlib.SlugInvalidCursor, appErr.Slug())
+		})
+	}
+}
+
+func Test_Codec_UnsupportedValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{
+			name:  "Struct",
+			value: struct{}{},
+		},
+		{
+			name:  "Nil",
+			value: nil,
+		},
+		{
+			name:  "Overflowing uint64",
+			value: uint64(math.MaxUint64),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newCodec(t, []byte("secret")).Encode(pagination.Cursor{Values: []any{tt.value}})
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kodenkai-labs/go-lib/errlib"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	ErrInvalidLimit  = errors.New("invalid limit")
+	ErrInvalidOffset = errors.New("invalid offset")
+	ErrInvalidSort   = errors.New("invalid sort")
+)
+
+// SortField is a field to sort by.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort parses a comma-separated list of fields, where a field prefixed with "-" is sorted in descending order,
+// e.g. "-created_at,id". Only the allowed fields are accepted.
+func ParseSort(raw string, allowed ...string) ([]SortField, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	sort := make([]SortField, 0, len(parts))
+	for _, part := range parts {
+		field := SortField{Field: strings.TrimSpace(part)}
+		if strings.HasPrefix(field.Field, "-") {
+			field.Field = field.Field[1:]
+			field.Desc = true
+		}
+
+		if !slices.Contains(allowed, field.Field) {
+			return nil, errlib.NewAppError(
+				fmt.Errorf("%w: field %q is not allowed", ErrInvalidSort, field.Field),
+				errlib.InvalidInputCode, errlib.SlugInvalidSort)
+		}
+
+		sort = append(sort, field)
+	}
+
+	return sort, nil
+}
+
+// FormatSort formats the sort in the format accepted by ParseSort.
+func FormatSort(sort []SortField) string {
+	parts := make([]string, 0, len(sort))
+	for _, field := range sort {
+		if field.Desc {
+			parts = append(parts, "-"+field.Field)
+		} else {
+			parts = append(parts, field.Field)
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// ParseLimit parses the page size. An empty value results in defaultLimit.
+// The value must be positive and not greater than maxLimit.
+func ParseLimit(raw string, defaultLimit, maxLimit int) (int, error) {
+	if raw == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 || limit > maxLimit {
+		return 0, errlib.NewAppError(
+			fmt.Errorf("%w: must be between 1 and %d", ErrInvalidLimit, maxLimit),
+			errlib.InvalidInputCode, errlib.SlugInvalidLimit)
+	}
+
+	return limit, nil
+}
+
+// ParseOffset parses the number of items to skip. An empty value results in 0.
+func ParseOffset(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+
+	offset, err := strconv.Atoi(raw)
+	if err != nil || offset < 0 {
+		return 0, errlib.NewAppError(
+			fmt.Errorf("%w: must be a non-negative integer", ErrInvalidOffset),
+			errlib.InvalidInputCode, errlib.SlugInvalidOffset)
+	}
+
+	return offset, nil
+}
+
+// Offset is an offset pagination request.
+type Offset struct {
+	Limit  int
+	Offset int
+	Sort   []SortField
+}
+
+// OffsetPage is a page of items returned for an offset pagination request.
+type OffsetPage[T any] struct {
+	Items  []T
+	Total  int64
+	Limit  int
+	Offset int
+}
+
+// HasNext reports whether there are items after the page.
+func (p OffsetPage[T]) HasNext() bool {
+	return int64(p.Offset+len(p.Items)) < p.Total
+}
+
+// HasPrev reports whether there are items before the page.
+func (p OffsetPage[T]) HasPrev() bool {
+	return p.Offset > 0
+}
+
+// Keyset is a keyset pagination request.
+// The sort must define a total order of the items, so it must end with a unique field, e.g. the primary key.
+// Sort fields must not contain NULL values: the rows can't be compared with them,
+// and Codec rejects nil cursor values.
+type Keyset struct {
+	Limit int
+	Sort  []SortField
+
+	// After contains the values of the sort fields of the item the page starts after.
+	// It is empty for the first page.
+	After []any
+
+	// Backward means the page contains the items preceding After.
+	Backward bool
+}
+
+// NewKeyset creates a keyset pagination request continuing from the cursor.
+// The cursor is nil for the first page.
+func NewKeyset(limit int, sort []SortField, cursor *Cursor) (Keyset, error) {
+	keyset := Keyset{Limit: limit, Sort: sort}
+	if cursor == nil {
+		return keyset, nil
+	}
+
+	if cursor.Sort != FormatSort(sort) || len(cursor.Values) != len(sort) {
+		return Keyset{}, errlib.NewAppError(
+			fmt.Errorf("%w: cursor doesn't match the sort", ErrInvalidCursor),
+			errlib.InvalidInputCode, errlib.SlugInvalidCursor)
+	}
+
+	keyset.After = cursor.Values
+	keyset.Backward = cursor.Backward
+
+	return keyset, nil
+}
+
+// KeysetPage is a page of items returned for a keyset pagination request.
+type KeysetPage[T any] struct {
+	Items []T
+
+	// Next is the cursor of the next page, nil if there is none.
+	Next *Cursor
+
+	// Prev is the cursor of the previous page, nil if there is none.
+	Prev *Cursor
+}
+
+// NewKeysetPage creates a page from the items fetched for the request.
+// The items must be fetched with the limit increased by one to detect whether there are more items,
+// e.g. by postgres.KeysetScope. values returns the values of the sort fields of the item.
+func NewKeysetPage[T any](items []T, req Keyset, values func(item T) []any) KeysetPage[T] {
+	hasMore := len(items) > req.Limit
+	if hasMore {
+		items = items[:req.Limit]
+	}
+	if req.Backward {
+		slices.Reverse(items)
+	}
+
+	page := KeysetPage[T]{Items: items}
+	if len(items) == 0 {
+		return page
+	}
+
+	sort := FormatSort(req.Sort)
+	hasNext := hasMore
+	hasPrev := len(req.After) > 0
+	if req.Backward {
+		hasNext, hasPrev = hasPrev, hasMore
+	}
+
+	if hasNext {
+		page.Next = &Cursor{Sort: sort, Values: values(items[len(items)-1])}
+	}
+	if hasPrev {
+		page.Prev = &Cursor{Sort: sort, Values: values(items[0]), Backward: true}
+	}
+
+	return page
+}
@@ -0,0 +1,157 @@
+package pagination_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kodenkai-labs/go-lib/errlib"
+	"github.com/kodenkai-labs/go-lib/pagination"
+)
+
+func Test_ParseSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    []pagination.SortField
+		wantErr bool
+	}{
+		{
+			name: "Empty sort",
+			raw:  "",
+			want: nil,
+		},
+		{
+			name: "Ascending and descending fields",
+			raw:  "-created_at,id",
+			want: []pagination.SortField{{Field: "created_at", Desc: true}, {Field: "id"}},
+		},
+		{
+			name:    "Not allowed field",
+			raw:     "password",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pagination.ParseSort(tt.raw, "created_at", "id")
+			if tt.wantErr {
+				var appErr errlib.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, errlib.InvalidInputCode, appErr.Code())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.raw, pagination.FormatSort(got))
+		})
+	}
+}
+
+func Test_ParseLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    int
+		wantErr bool
+	}{
+		{name: "Default limit", raw: "", want: 20},
+		{name: "Valid limit", raw: "50", want: 50},
+		{name: "Zero limit", raw: "0", wantErr: true},
+		{name: "Limit above maximum", raw: "101", wantErr: true},
+		{name: "Not a number", raw: "ten", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pagination.ParseLimit(tt.raw, pagination.DefaultLimit, pagination.MaxLimit)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_NewKeyset(t *testing.T) {
+	sort := []pagination.SortField{{Field: "created_at", Desc: true}, {Field: "id"}}
+
+	keyset, err := pagination.NewKeyset(10, sort, &pagination.Cursor{Sort: "-created_at,id", Values: []any{"t", 1}})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"t", 1}, keyset.After)
+
+	_, err = pagination.NewKeyset(10, sort, &pagination.Cursor{Sort: "id", Values: []any{1}})
+	assert.Error(t, err)
+}
+
+func Test_NewKeysetPage(t *testing.T) {
+	sort := []pagination.SortField{{Field: "id"}}
+	values := func(item int) []any { return []any{item} }
+
+	tests := []struct {
+		name     string
+		items    []int
+		req      pagination.Keyset
+		want     []int
+		wantNext []any
+		wantPrev []any
+	}{
+		{
+			name:     "First page with more items",
+			items:    []int{1, 2, 3},
+			req:      pagination.Keyset{Limit: 2, Sort: sort},
+			want:     []int{1, 2},
+			wantNext: []any{2},
+		},
+		{
+			name:     "Last page",
+			items:    []int{3, 4},
+			req:      pagination.Keyset{Limit: 2, Sort: sort, After: []any{2}},
+			want:     []int{3, 4},
+			wantPrev: []any{3},
+		},
+		{
+			name:     "Backward page with more items",
+			items:    []int{4, 3, 2},
+			req:      pagination.Keyset{Limit: 2, Sort: sort, After: []any{5}, Backward: true},
+			want:     []int{3, 4},
+			wantNext: []any{4},
+			wantPrev: []any{3},
+		},
+		{
+			name:  "Empty page",
+			items: nil,
+			req:   pagination.Keyset{Limit: 2, Sort: sort},
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := pagination.NewKeysetPage(tt.items, tt.req, values)
+			assert.Equal(t, tt.want, page.Items)
+
+			if tt.wantNext == nil {
+				assert.Nil(t, page.Next)
+			} else {
+				require.NotNil(t, page.Next)
+				assert.Equal(t, tt.wantNext, page.Next.Values)
+				assert.False(t, page.Next.Backward)
+			}
+
+			if tt.wantPrev == nil {
+				assert.Nil(t, page.Prev)
+			} else {
+				require.NotNil(t, page.Prev)
+				assert.Equal(t, tt.wantPrev, page.Prev.Values)
+				assert.True(t, page.Prev.Backward)
+			}
+		})
+	}
+}